@@ -0,0 +1,104 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+type Item struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type IngestError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// The reply from /ingest.  Lines are numbered from 1 in the order the items
+// were sent.
+type IngestSummary struct {
+	Lines  int           `json:"lines"`
+	Added  int           `json:"added"`
+	Failed int           `json:"failed"`
+	Errors []IngestError `json:"errors"`
+}
+
+// Adds every item with a single request to /ingest.  A k of 0 uses the
+// server's default size for sets that get created.
+func (c *Client) Ingest(items []Item, k int) (*IngestSummary, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return nil, err
+		}
+	}
+
+	params := url.Values{}
+	if k > 0 {
+		params.Set("k", strconv.Itoa(k))
+	}
+	var summary IngestSummary
+	err := c.do("/ingest", params, body.Bytes(), &summary)
+	return &summary, err
+}
+
+// Collects values and sends them to the server with Ingest once Size of them
+// have been added.  A Batch can be used from many goroutines at once.
+type Batch struct {
+	sync.Mutex
+	client *Client
+	size   int
+	k      int
+	items  []Item
+
+	// Totals over every request the batch has sent
+	Added  int
+	Failed int
+}
+
+func (c *Client) NewBatch(size int, k int) *Batch {
+	return &Batch{
+		client: c,
+		size:   size,
+		k:      k,
+		items:  make([]Item, 0, size),
+	}
+}
+
+// Queues value to be added to key, sending the batch if it is full.  Errors
+// only come from sending the batch, in which case its items are dropped.
+func (b *Batch) Add(key, value string) error {
+	b.Lock()
+	defer b.Unlock()
+	b.items = append(b.items, Item{key, value})
+	if len(b.items) < b.size {
+		return nil
+	}
+	return b.flush()
+}
+
+// Sends every queued item
+func (b *Batch) Flush() error {
+	b.Lock()
+	defer b.Unlock()
+	return b.flush()
+}
+
+func (b *Batch) flush() error {
+	if len(b.items) == 0 {
+		return nil
+	}
+	summary, err := b.client.Ingest(b.items, b.k)
+	b.items = b.items[:0]
+	if err != nil {
+		return err
+	}
+	b.Added += summary.Added
+	b.Failed += summary.Failed
+	return nil
+}
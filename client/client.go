@@ -0,0 +1,307 @@
+// Package client talks to a gocountme server over its HTTP API.
+//
+//	c := client.NewClient("http://localhost:8080")
+//	c.Add("users", "alice", nil)
+//	estimate, err := c.Cardinality("users")
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("Key not found")
+)
+
+// Returned when the server replies with an error.  Status holds the server's
+// error, eg: MISSING_ARG_KEY or the message of the error that occurred.
+type ServerError struct {
+	StatusCode int
+	Status     string
+}
+
+func (se *ServerError) Error() string {
+	return fmt.Sprintf("gocountme: %d %s", se.StatusCode, se.Status)
+}
+
+// An estimated number along with the interval that holds the true value with
+// the given confidence
+type Estimate struct {
+	Estimate   float64 `json:"estimate"`
+	Lower      float64 `json:"lower"`
+	Upper      float64 `json:"upper"`
+	Confidence float64 `json:"confidence"`
+	Exact      bool    `json:"exact"`
+}
+
+type CorrelationElement struct {
+	Keys    [2]string `json:"keys"`
+	Jaccard *Estimate `json:"jaccard"`
+}
+
+type QueryResult struct {
+	Key      string          `json:"key"`
+	Set      json.RawMessage `json:"set"`
+	Result   float64         `json:"result"`
+	Interval *Estimate       `json:"interval,omitempty"`
+	Multi    []*QueryResult  `json:"multi_result,omitempty"`
+}
+
+type KeyInfo struct {
+	Key         string  `json:"key"`
+	Type        string  `json:"type,omitempty"`
+	Cardinality float64 `json:"cardinality,omitempty"`
+	K           int     `json:"k,omitempty"`
+	Exact       bool    `json:"exact,omitempty"`
+}
+
+type KeysPage struct {
+	Keys   []KeyInfo `json:"keys"`
+	Cursor string    `json:"cursor"`
+}
+
+// Options for sets that are created by an add.  The zero value uses the
+// server's defaults.
+type AddOptions struct {
+	K    int
+	Type string // kmv, hll or theta
+	TTL  time.Duration
+	// Adds to the time bucket holding Timestamp instead of the key itself
+	Timestamp time.Time
+	Bucket    string // minute, hour or day
+}
+
+func (ao *AddOptions) params(params url.Values) {
+	if ao == nil {
+		return
+	}
+	if ao.K > 0 {
+		params.Set("k", strconv.Itoa(ao.K))
+	}
+	if ao.Type != "" {
+		params.Set("type", ao.Type)
+	}
+	if ao.TTL > 0 {
+		params.Set("ttl", strconv.FormatInt(int64(ao.TTL/time.Second), 10))
+	}
+	if !ao.Timestamp.IsZero() {
+		params.Set("ts", strconv.FormatInt(ao.Timestamp.Unix(), 10))
+		if ao.Bucket != "" {
+			params.Set("bucket", ao.Bucket)
+		}
+	}
+}
+
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Number of times a request is retried when the server can't be reached
+	// or is shutting down.  Every call the client makes is safe to retry.
+	Retries int
+	// How long to wait before the first retry, doubled for every one after
+	RetryWait time.Duration
+	// Confidence level of the intervals around estimates, 0 uses the
+	// server's default
+	Confidence float64
+}
+
+// Creates a client for the server at baseURL, eg: http://localhost:8080.  The
+// client keeps a pool of connections to the server and can be used from many
+// goroutines at once.
+func NewClient(baseURL string) *Client {
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        64,
+		MaxIdleConnsPerHost: 64,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		Retries:    3,
+		RetryWait:  100 * time.Millisecond,
+	}
+}
+
+type envelope struct {
+	StatusCode int             `json:"status_code"`
+	StatusTxt  string          `json:"status_txt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Sends a request to endpoint and decodes the data of the reply into result
+// when it isn't nil.  Requests with a body are POSTed.
+func (c *Client) do(endpoint string, params url.Values, body []byte, result interface{}) error {
+	uri := c.BaseURL + endpoint + "?" + params.Encode()
+	wait := c.RetryWait
+	var err error
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		var retry bool
+		retry, err = c.try(uri, body, result)
+		if !retry {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *Client) try(uri string, body []byte, result interface{}) (bool, error) {
+	var response *http.Response
+	var err error
+	if body == nil {
+		response, err = c.HTTPClient.Get(uri)
+	} else {
+		response, err = c.HTTPClient.Post(uri, "application/x-ndjson", bytes.NewReader(body))
+	}
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+
+	raw, err := io.ReadAll(response.Body)
+	if err != nil {
+		return true, err
+	}
+	if response.StatusCode == http.StatusServiceUnavailable {
+		return true, &ServerError{response.StatusCode, "SHUTTING_DOWN"}
+	}
+
+	var reply envelope
+	if err := json.Unmarshal(raw, &reply); err != nil {
+		return false, &ServerError{response.StatusCode, strings.TrimSpace(string(raw))}
+	}
+	if response.StatusCode != http.StatusOK {
+		status := reply.StatusTxt
+		if status == "" {
+			json.Unmarshal(reply.Data, &status)
+		}
+		return false, &ServerError{response.StatusCode, status}
+	}
+	if result != nil {
+		return false, json.Unmarshal(reply.Data, result)
+	}
+	return false, nil
+}
+
+func (c *Client) confidence(params url.Values) url.Values {
+	if c.Confidence != 0 {
+		params.Set("confidence", strconv.FormatFloat(c.Confidence, 'f', -1, 64))
+	}
+	return params
+}
+
+func (c *Client) Add(key, value string, opts *AddOptions) error {
+	params := url.Values{"key": {key}, "value": {value}}
+	opts.params(params)
+	return c.do("/add", params, nil, nil)
+}
+
+func (c *Client) AddHash(key string, hash uint64, opts *AddOptions) error {
+	params := url.Values{"key": {key}, "hash": {strconv.FormatUint(hash, 10)}}
+	opts.params(params)
+	return c.do("/addhash", params, nil, nil)
+}
+
+// Returns the set stored at key as the server encodes it
+func (c *Client) Get(key string) (json.RawMessage, error) {
+	var result struct {
+		Data  json.RawMessage
+		Error interface{}
+	}
+	err := c.do("/get", url.Values{"key": {key}}, nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	// /get only tells us that something went wrong, which is almost always
+	// that the key doesn't exist
+	if result.Error != nil {
+		return nil, ErrNotFound
+	}
+	return result.Data, nil
+}
+
+func (c *Client) Delete(key string) error {
+	return c.do("/delete", url.Values{"key": {key}}, nil, nil)
+}
+
+// Makes key expire ttl from now.  A ttl of 0 keeps the key forever.
+func (c *Client) Expire(key string, ttl time.Duration) error {
+	params := url.Values{"key": {key}, "ttl": {strconv.FormatInt(int64(ttl/time.Second), 10)}}
+	return c.do("/expire", params, nil, nil)
+}
+
+func (c *Client) Cardinality(key string) (*Estimate, error) {
+	var estimate Estimate
+	err := c.do("/cardinality", c.confidence(url.Values{"key": {key}}), nil, &estimate)
+	return &estimate, err
+}
+
+// Gives the cardinality of the union of every time bucket of key between from
+// and to.  An empty bucket uses the server's default.
+func (c *Client) CardinalityRange(key string, from, to time.Time, bucket string) (*Estimate, error) {
+	params := url.Values{
+		"key":  {key},
+		"from": {strconv.FormatInt(from.Unix(), 10)},
+		"to":   {strconv.FormatInt(to.Unix(), 10)},
+	}
+	if bucket != "" {
+		params.Set("bucket", bucket)
+	}
+	var estimate Estimate
+	err := c.do("/cardinality", c.confidence(params), nil, &estimate)
+	return &estimate, err
+}
+
+func (c *Client) Jaccard(key1, key2 string) (*Estimate, error) {
+	var estimate Estimate
+	err := c.do("/jaccard", c.confidence(url.Values{"key": {key1, key2}}), nil, &estimate)
+	return &estimate, err
+}
+
+// Gives the fraction of key1's items that are also in key2
+func (c *Client) Containment(key1, key2 string) (*Estimate, error) {
+	var estimate Estimate
+	err := c.do("/containment", c.confidence(url.Values{"key": {key1, key2}}), nil, &estimate)
+	return &estimate, err
+}
+
+func (c *Client) Correlation(keys ...string) ([]CorrelationElement, error) {
+	var matrix []CorrelationElement
+	err := c.do("/correlation", c.confidence(url.Values{"key": keys}), nil, &matrix)
+	return matrix, err
+}
+
+func (c *Client) Query(query *Query) (*QueryResult, error) {
+	raw, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	var result QueryResult
+	err = c.do("/query", c.confidence(url.Values{"q": {string(raw)}}), nil, &result)
+	return &result, err
+}
+
+// Lists up to limit keys starting with prefix.  Pass the returned page's
+// cursor to get the next page, which is empty after the last page.
+func (c *Client) Keys(prefix, cursor string, limit int) (*KeysPage, error) {
+	params := url.Values{"prefix": {prefix}, "cursor": {cursor}, "details": {"true"}}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	var page KeysPage
+	err := c.do("/keys", params, nil, &page)
+	return &page, err
+}
@@ -0,0 +1,55 @@
+package client
+
+// A node of the query tree sent to /query.  Every node takes its data from
+// exactly one of keys, nested queries or a key prefix:
+//
+//	// |(a u b) n c|
+//	query := client.Cardinality().FromSets(
+//		client.Intersection().FromSets(
+//			client.Union().FromKeys("a", "b"),
+//			client.Get("c"),
+//		),
+//	)
+type Query struct {
+	Method string   `json:"method"`
+	Set    []*Query `json:"set,omitempty"`
+	Keys   []string `json:"keys,omitempty"`
+	Prefix string   `json:"prefix,omitempty"`
+}
+
+func NewQuery(method string) *Query {
+	return &Query{Method: method}
+}
+
+func (q *Query) FromKeys(keys ...string) *Query {
+	q.Keys = append(q.Keys, keys...)
+	return q
+}
+
+func (q *Query) FromSets(sets ...*Query) *Query {
+	q.Set = append(q.Set, sets...)
+	return q
+}
+
+// Uses every key starting with prefix, or matching it if it is a glob such
+// as `users:2026-10-*`
+func (q *Query) FromPrefix(prefix string) *Query {
+	q.Prefix = prefix
+	return q
+}
+
+func Get(key string) *Query { return NewQuery("get").FromKeys(key) }
+
+func Cardinality() *Query             { return NewQuery("cardinality") }
+func Union() *Query                   { return NewQuery("union") }
+func Intersection() *Query            { return NewQuery("intersection") }
+func Difference() *Query              { return NewQuery("difference") }
+func Jaccard() *Query                 { return NewQuery("jaccard") }
+func Containment() *Query             { return NewQuery("containment") }
+func CardinalityUnion() *Query        { return NewQuery("cardinality_union") }
+func CardinalityIntersection() *Query { return NewQuery("cardinality_intersection") }
+func Correlation() *Query             { return NewQuery("correlation") }
+
+// Sum and Mean only work on weighted keys
+func Sum() *Query  { return NewQuery("sum") }
+func Mean() *Query { return NewQuery("mean") }
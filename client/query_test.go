@@ -0,0 +1,22 @@
+package client
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	query := Cardinality().FromSets(
+		Intersection().FromSets(
+			Union().FromKeys("a", "b"),
+			Get("c"),
+		),
+	)
+	raw, err := json.Marshal(query)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(raw), `{"method":"cardinality","set":[{"method":"intersection","set":[{"method":"union","keys":["a","b"]},{"method":"get","keys":["c"]}]}]}`)
+
+	raw, _ = json.Marshal(Correlation().FromPrefix("users:"))
+	assert.Equal(t, string(raw), `{"method":"correlation","prefix":"users:"}`)
+}
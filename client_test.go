@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/client"
+	"github.com/mynameisfiber/gocountme/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testServer() *httptest.Server {
+	mux := http.NewServeMux()
+	registerHandlers(mux)
+	return httptest.NewServer(shutdownGate(mux))
+}
+
+func TestClient(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+	server := testServer()
+	defer server.Close()
+
+	c := client.NewClient(server.URL)
+	keys := []string{"_GOTEST_CLIENT1", "_GOTEST_CLIENT2"}
+	for _, key := range keys {
+		assert.Equal(t, c.Delete(key), nil)
+		defer c.Delete(key)
+	}
+
+	assert.Equal(t, c.Add(keys[0], "a", &client.AddOptions{K: 10}), nil)
+	assert.Equal(t, c.Add(keys[0], "b", nil), nil)
+	assert.Equal(t, c.AddHash(keys[1], store.Hashify([]byte("b")), nil), nil)
+	assert.Equal(t, c.Add(keys[1], "c", nil), nil)
+
+	estimate, err := c.Cardinality(keys[0])
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *estimate, client.Estimate{Estimate: 2, Lower: 2, Upper: 2, Confidence: store.DefaultConfidence, Exact: true})
+
+	c.Confidence = 0.9
+	jaccard, err := c.Jaccard(keys[0], keys[1])
+	assert.Equal(t, err, nil)
+	assert.Equal(t, jaccard.Estimate, 1.0/3.0)
+	assert.Equal(t, jaccard.Confidence, 0.9)
+
+	containment, err := c.Containment(keys[0], keys[1])
+	assert.Equal(t, err, nil)
+	assert.Equal(t, containment.Estimate, 0.5)
+
+	matrix, err := c.Correlation(keys...)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(matrix), 1)
+
+	query := client.Cardinality().FromSets(client.Union().FromKeys(keys...))
+	result, err := c.Query(query)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Result, 3.0)
+	assert.Equal(t, result.Interval.Exact, true)
+
+	_, err = c.Query(client.Containment().FromKeys(keys[0]))
+	assert.Equal(t, err, &client.ServerError{StatusCode: 500, Status: store.ContainmentTwoTerms.Error()})
+
+	set, err := c.Get(keys[0])
+	assert.Equal(t, err, nil)
+	assert.NotEqual(t, len(set), 0)
+	assert.Equal(t, c.Delete(keys[0]), nil)
+	_, err = c.Get(keys[0])
+	assert.Equal(t, err, client.ErrNotFound)
+
+	_, err = c.Cardinality("")
+	assert.Equal(t, err, &client.ServerError{StatusCode: 500, Status: "MISSING_ARG_KEY"})
+}
+
+func TestClientBatch(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+	server := testServer()
+	defer server.Close()
+
+	c := client.NewClient(server.URL)
+	key := "_GOTEST_CLIENT_BATCH"
+	c.Delete(key)
+	defer c.Delete(key)
+
+	batch := c.NewBatch(3, 100)
+	for _, value := range []string{"a", "b", "c", "d", "e"} {
+		assert.Equal(t, batch.Add(key, value), nil)
+	}
+	assert.Equal(t, batch.Added, 3)
+	assert.Equal(t, batch.Flush(), nil)
+	assert.Equal(t, batch.Added, 5)
+
+	summary, err := c.Ingest([]client.Item{{Key: key, Value: "f"}, {Key: "", Value: "g"}}, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, summary.Added, 1)
+	assert.Equal(t, summary.Errors, []client.IngestError{{Line: 2, Error: store.NoKeySpecified.Error()}})
+
+	page, err := c.Keys(key, "", 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, page.Keys, []client.KeyInfo{{Key: key, Type: "kmv", Cardinality: 6, K: 100, Exact: true}})
+}
+
+func TestClientRetries(t *testing.T) {
+	defer func() { shuttingDown = false }()
+	server := testServer()
+	defer server.Close()
+
+	c := client.NewClient(server.URL)
+	c.RetryWait = time.Millisecond
+	c.Retries = 10
+
+	// requests are refused while shutting down and retried until we stop
+	drainRequests(context.Background())
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		shutdownLock.Lock()
+		shuttingDown = false
+		shutdownLock.Unlock()
+	}()
+	_, err := c.Cardinality("")
+	assert.Equal(t, err, &client.ServerError{StatusCode: 500, Status: "MISSING_ARG_KEY"})
+
+	c.Retries = 0
+	drainRequests(context.Background())
+	_, err = c.Cardinality("")
+	assert.Equal(t, err, &client.ServerError{StatusCode: 503, Status: "SHUTTING_DOWN"})
+}
@@ -0,0 +1,7 @@
+// Package gocountmepb holds the protobuf messages and gRPC service served by
+// gocountme when it is built with the grpc tag.  The generated code is checked
+// in, run `go generate ./gocountmepb` with protoc, protoc-gen-go and
+// protoc-gen-go-grpc installed to update it after changing gocountme.proto.
+package gocountmepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gocountme.proto
@@ -0,0 +1,1430 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: gocountme.proto
+
+package gocountmepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SketchType int32
+
+const (
+	SketchType_KMV   SketchType = 0
+	SketchType_HLL   SketchType = 1
+	SketchType_THETA SketchType = 2
+)
+
+// Enum value maps for SketchType.
+var (
+	SketchType_name = map[int32]string{
+		0: "KMV",
+		1: "HLL",
+		2: "THETA",
+	}
+	SketchType_value = map[string]int32{
+		"KMV":   0,
+		"HLL":   1,
+		"THETA": 2,
+	}
+)
+
+func (x SketchType) Enum() *SketchType {
+	p := new(SketchType)
+	*p = x
+	return p
+}
+
+func (x SketchType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SketchType) Descriptor() protoreflect.EnumDescriptor {
+	return file_gocountme_proto_enumTypes[0].Descriptor()
+}
+
+func (SketchType) Type() protoreflect.EnumType {
+	return &file_gocountme_proto_enumTypes[0]
+}
+
+func (x SketchType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SketchType.Descriptor instead.
+func (SketchType) EnumDescriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{0}
+}
+
+type ErrorCode int32
+
+const (
+	ErrorCode_UNKNOWN                 ErrorCode = 0
+	ErrorCode_MISSING_KEY             ErrorCode = 1
+	ErrorCode_KEY_NOT_FOUND           ErrorCode = 2
+	ErrorCode_INVALID_ARGUMENT        ErrorCode = 3
+	ErrorCode_UNSUPPORTED_SKETCH_TYPE ErrorCode = 4
+	ErrorCode_MIXED_SKETCH_TYPES      ErrorCode = 5
+	ErrorCode_INVALID_QUERY           ErrorCode = 6
+	ErrorCode_SHUTTING_DOWN           ErrorCode = 7
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "MISSING_KEY",
+		2: "KEY_NOT_FOUND",
+		3: "INVALID_ARGUMENT",
+		4: "UNSUPPORTED_SKETCH_TYPE",
+		5: "MIXED_SKETCH_TYPES",
+		6: "INVALID_QUERY",
+		7: "SHUTTING_DOWN",
+	}
+	ErrorCode_value = map[string]int32{
+		"UNKNOWN":                 0,
+		"MISSING_KEY":             1,
+		"KEY_NOT_FOUND":           2,
+		"INVALID_ARGUMENT":        3,
+		"UNSUPPORTED_SKETCH_TYPE": 4,
+		"MIXED_SKETCH_TYPES":      5,
+		"INVALID_QUERY":           6,
+		"SHUTTING_DOWN":           7,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_gocountme_proto_enumTypes[1].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_gocountme_proto_enumTypes[1]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{1}
+}
+
+// Attached to the status of every failed call
+type Error struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  ErrorCode              `protobuf:"varint,1,opt,name=code,proto3,enum=gocountme.ErrorCode" json:"code,omitempty"`
+	// the request argument that was invalid, if any
+	Argument      string `protobuf:"bytes,2,opt,name=argument,proto3" json:"argument,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_gocountme_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Error) GetCode() ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ErrorCode_UNKNOWN
+}
+
+func (x *Error) GetArgument() string {
+	if x != nil {
+		return x.Argument
+	}
+	return ""
+}
+
+type KeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyRequest) Reset() {
+	*x = KeyRequest{}
+	mi := &file_gocountme_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyRequest) ProtoMessage() {}
+
+func (x *KeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyRequest.ProtoReflect.Descriptor instead.
+func (*KeyRequest) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KeyRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type Sketch struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Key         string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Type        SketchType             `protobuf:"varint,2,opt,name=type,proto3,enum=gocountme.SketchType" json:"type,omitempty"`
+	K           int32                  `protobuf:"varint,3,opt,name=k,proto3" json:"k,omitempty"`
+	Exact       bool                   `protobuf:"varint,4,opt,name=exact,proto3" json:"exact,omitempty"`
+	Cardinality float64                `protobuf:"fixed64,5,opt,name=cardinality,proto3" json:"cardinality,omitempty"`
+	// the set as it is stored in the database
+	Data          []byte `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sketch) Reset() {
+	*x = Sketch{}
+	mi := &file_gocountme_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sketch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sketch) ProtoMessage() {}
+
+func (x *Sketch) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sketch.ProtoReflect.Descriptor instead.
+func (*Sketch) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Sketch) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Sketch) GetType() SketchType {
+	if x != nil {
+		return x.Type
+	}
+	return SketchType_KMV
+}
+
+func (x *Sketch) GetK() int32 {
+	if x != nil {
+		return x.K
+	}
+	return 0
+}
+
+func (x *Sketch) GetExact() bool {
+	if x != nil {
+		return x.Exact
+	}
+	return false
+}
+
+func (x *Sketch) GetCardinality() float64 {
+	if x != nil {
+		return x.Cardinality
+	}
+	return 0
+}
+
+func (x *Sketch) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type DeleteReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteReply) Reset() {
+	*x = DeleteReply{}
+	mi := &file_gocountme_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteReply) ProtoMessage() {}
+
+func (x *DeleteReply) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteReply.ProtoReflect.Descriptor instead.
+func (*DeleteReply) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{3}
+}
+
+// The k, type and ttl_seconds of a request only apply when it creates the set.
+// A k of 0 uses the key size config.  When ts is set the value is added to
+// the time bucket of key holding ts.
+type AddRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	K             int32                  `protobuf:"varint,3,opt,name=k,proto3" json:"k,omitempty"`
+	Type          SketchType             `protobuf:"varint,4,opt,name=type,proto3,enum=gocountme.SketchType" json:"type,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,5,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	Ts            *int64                 `protobuf:"varint,6,opt,name=ts,proto3,oneof" json:"ts,omitempty"`
+	Bucket        string                 `protobuf:"bytes,7,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddRequest) Reset() {
+	*x = AddRequest{}
+	mi := &file_gocountme_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddRequest) ProtoMessage() {}
+
+func (x *AddRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddRequest.ProtoReflect.Descriptor instead.
+func (*AddRequest) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AddRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *AddRequest) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *AddRequest) GetK() int32 {
+	if x != nil {
+		return x.K
+	}
+	return 0
+}
+
+func (x *AddRequest) GetType() SketchType {
+	if x != nil {
+		return x.Type
+	}
+	return SketchType_KMV
+}
+
+func (x *AddRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *AddRequest) GetTs() int64 {
+	if x != nil && x.Ts != nil {
+		return *x.Ts
+	}
+	return 0
+}
+
+func (x *AddRequest) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+type AddHashRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Hash          uint64                 `protobuf:"varint,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	K             int32                  `protobuf:"varint,3,opt,name=k,proto3" json:"k,omitempty"`
+	Type          SketchType             `protobuf:"varint,4,opt,name=type,proto3,enum=gocountme.SketchType" json:"type,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,5,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	Ts            *int64                 `protobuf:"varint,6,opt,name=ts,proto3,oneof" json:"ts,omitempty"`
+	Bucket        string                 `protobuf:"bytes,7,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddHashRequest) Reset() {
+	*x = AddHashRequest{}
+	mi := &file_gocountme_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddHashRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddHashRequest) ProtoMessage() {}
+
+func (x *AddHashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddHashRequest.ProtoReflect.Descriptor instead.
+func (*AddHashRequest) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AddHashRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *AddHashRequest) GetHash() uint64 {
+	if x != nil {
+		return x.Hash
+	}
+	return 0
+}
+
+func (x *AddHashRequest) GetK() int32 {
+	if x != nil {
+		return x.K
+	}
+	return 0
+}
+
+func (x *AddHashRequest) GetType() SketchType {
+	if x != nil {
+		return x.Type
+	}
+	return SketchType_KMV
+}
+
+func (x *AddHashRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *AddHashRequest) GetTs() int64 {
+	if x != nil && x.Ts != nil {
+		return *x.Ts
+	}
+	return 0
+}
+
+func (x *AddHashRequest) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+type AddReply struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the key the value was added to, which is the bucket key when ts is set
+	Key           string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddReply) Reset() {
+	*x = AddReply{}
+	mi := &file_gocountme_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddReply) ProtoMessage() {}
+
+func (x *AddReply) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddReply.ProtoReflect.Descriptor instead.
+func (*AddReply) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AddReply) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type AddStreamError struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// index of the value in the stream, starting at 0
+	Index         int64  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Error         *Error `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Message       string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddStreamError) Reset() {
+	*x = AddStreamError{}
+	mi := &file_gocountme_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddStreamError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddStreamError) ProtoMessage() {}
+
+func (x *AddStreamError) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddStreamError.ProtoReflect.Descriptor instead.
+func (*AddStreamError) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AddStreamError) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *AddStreamError) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *AddStreamError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type AddStreamReply struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Added  int64                  `protobuf:"varint,1,opt,name=added,proto3" json:"added,omitempty"`
+	Failed int64                  `protobuf:"varint,2,opt,name=failed,proto3" json:"failed,omitempty"`
+	// the first errors, at most 100 of them
+	Errors        []*AddStreamError `protobuf:"bytes,3,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddStreamReply) Reset() {
+	*x = AddStreamReply{}
+	mi := &file_gocountme_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddStreamReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddStreamReply) ProtoMessage() {}
+
+func (x *AddStreamReply) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddStreamReply.ProtoReflect.Descriptor instead.
+func (*AddStreamReply) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AddStreamReply) GetAdded() int64 {
+	if x != nil {
+		return x.Added
+	}
+	return 0
+}
+
+func (x *AddStreamReply) GetFailed() int64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *AddStreamReply) GetErrors() []*AddStreamError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+// A confidence of 0 uses the default of 0.95
+type Estimate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Estimate      float64                `protobuf:"fixed64,1,opt,name=estimate,proto3" json:"estimate,omitempty"`
+	Lower         float64                `protobuf:"fixed64,2,opt,name=lower,proto3" json:"lower,omitempty"`
+	Upper         float64                `protobuf:"fixed64,3,opt,name=upper,proto3" json:"upper,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,4,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Exact         bool                   `protobuf:"varint,5,opt,name=exact,proto3" json:"exact,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Estimate) Reset() {
+	*x = Estimate{}
+	mi := &file_gocountme_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Estimate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Estimate) ProtoMessage() {}
+
+func (x *Estimate) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Estimate.ProtoReflect.Descriptor instead.
+func (*Estimate) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Estimate) GetEstimate() float64 {
+	if x != nil {
+		return x.Estimate
+	}
+	return 0
+}
+
+func (x *Estimate) GetLower() float64 {
+	if x != nil {
+		return x.Lower
+	}
+	return 0
+}
+
+func (x *Estimate) GetUpper() float64 {
+	if x != nil {
+		return x.Upper
+	}
+	return 0
+}
+
+func (x *Estimate) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Estimate) GetExact() bool {
+	if x != nil {
+		return x.Exact
+	}
+	return false
+}
+
+// When from is set this gives the cardinality of the union of every time
+// bucket of key between from and to, which defaults to now
+type CardinalityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	From          *int64                 `protobuf:"varint,3,opt,name=from,proto3,oneof" json:"from,omitempty"`
+	To            *int64                 `protobuf:"varint,4,opt,name=to,proto3,oneof" json:"to,omitempty"`
+	Bucket        string                 `protobuf:"bytes,5,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CardinalityRequest) Reset() {
+	*x = CardinalityRequest{}
+	mi := &file_gocountme_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CardinalityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CardinalityRequest) ProtoMessage() {}
+
+func (x *CardinalityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CardinalityRequest.ProtoReflect.Descriptor instead.
+func (*CardinalityRequest) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CardinalityRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CardinalityRequest) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *CardinalityRequest) GetFrom() int64 {
+	if x != nil && x.From != nil {
+		return *x.From
+	}
+	return 0
+}
+
+func (x *CardinalityRequest) GetTo() int64 {
+	if x != nil && x.To != nil {
+		return *x.To
+	}
+	return 0
+}
+
+func (x *CardinalityRequest) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+type JaccardRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key1          string                 `protobuf:"bytes,1,opt,name=key1,proto3" json:"key1,omitempty"`
+	Key2          string                 `protobuf:"bytes,2,opt,name=key2,proto3" json:"key2,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JaccardRequest) Reset() {
+	*x = JaccardRequest{}
+	mi := &file_gocountme_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JaccardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JaccardRequest) ProtoMessage() {}
+
+func (x *JaccardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JaccardRequest.ProtoReflect.Descriptor instead.
+func (*JaccardRequest) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *JaccardRequest) GetKey1() string {
+	if x != nil {
+		return x.Key1
+	}
+	return ""
+}
+
+func (x *JaccardRequest) GetKey2() string {
+	if x != nil {
+		return x.Key2
+	}
+	return ""
+}
+
+func (x *JaccardRequest) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type CorrelationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CorrelationRequest) Reset() {
+	*x = CorrelationRequest{}
+	mi := &file_gocountme_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CorrelationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CorrelationRequest) ProtoMessage() {}
+
+func (x *CorrelationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CorrelationRequest.ProtoReflect.Descriptor instead.
+func (*CorrelationRequest) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CorrelationRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *CorrelationRequest) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type CorrelationElement struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key1          string                 `protobuf:"bytes,1,opt,name=key1,proto3" json:"key1,omitempty"`
+	Key2          string                 `protobuf:"bytes,2,opt,name=key2,proto3" json:"key2,omitempty"`
+	Jaccard       *Estimate              `protobuf:"bytes,3,opt,name=jaccard,proto3" json:"jaccard,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CorrelationElement) Reset() {
+	*x = CorrelationElement{}
+	mi := &file_gocountme_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CorrelationElement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CorrelationElement) ProtoMessage() {}
+
+func (x *CorrelationElement) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CorrelationElement.ProtoReflect.Descriptor instead.
+func (*CorrelationElement) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CorrelationElement) GetKey1() string {
+	if x != nil {
+		return x.Key1
+	}
+	return ""
+}
+
+func (x *CorrelationElement) GetKey2() string {
+	if x != nil {
+		return x.Key2
+	}
+	return ""
+}
+
+func (x *CorrelationElement) GetJaccard() *Estimate {
+	if x != nil {
+		return x.Jaccard
+	}
+	return nil
+}
+
+type CorrelationReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Elements      []*CorrelationElement  `protobuf:"bytes,1,rep,name=elements,proto3" json:"elements,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CorrelationReply) Reset() {
+	*x = CorrelationReply{}
+	mi := &file_gocountme_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CorrelationReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CorrelationReply) ProtoMessage() {}
+
+func (x *CorrelationReply) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CorrelationReply.ProtoReflect.Descriptor instead.
+func (*CorrelationReply) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CorrelationReply) GetElements() []*CorrelationElement {
+	if x != nil {
+		return x.Elements
+	}
+	return nil
+}
+
+// query is the same JSON query as the `q` parameter of /query
+type QueryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_gocountme_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *QueryRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *QueryRequest) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type QueryReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Set           *Sketch                `protobuf:"bytes,2,opt,name=set,proto3" json:"set,omitempty"`
+	Result        float64                `protobuf:"fixed64,3,opt,name=result,proto3" json:"result,omitempty"`
+	Interval      *Estimate              `protobuf:"bytes,4,opt,name=interval,proto3" json:"interval,omitempty"`
+	Multi         []*QueryReply          `protobuf:"bytes,5,rep,name=multi,proto3" json:"multi,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryReply) Reset() {
+	*x = QueryReply{}
+	mi := &file_gocountme_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryReply) ProtoMessage() {}
+
+func (x *QueryReply) ProtoReflect() protoreflect.Message {
+	mi := &file_gocountme_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryReply.ProtoReflect.Descriptor instead.
+func (*QueryReply) Descriptor() ([]byte, []int) {
+	return file_gocountme_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *QueryReply) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *QueryReply) GetSet() *Sketch {
+	if x != nil {
+		return x.Set
+	}
+	return nil
+}
+
+func (x *QueryReply) GetResult() float64 {
+	if x != nil {
+		return x.Result
+	}
+	return 0
+}
+
+func (x *QueryReply) GetInterval() *Estimate {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *QueryReply) GetMulti() []*QueryReply {
+	if x != nil {
+		return x.Multi
+	}
+	return nil
+}
+
+var File_gocountme_proto protoreflect.FileDescriptor
+
+const file_gocountme_proto_rawDesc = "" +
+	"\n" +
+	"\x0fgocountme.proto\x12\tgocountme\"M\n" +
+	"\x05Error\x12(\n" +
+	"\x04code\x18\x01 \x01(\x0e2\x14.gocountme.ErrorCodeR\x04code\x12\x1a\n" +
+	"\bargument\x18\x02 \x01(\tR\bargument\"\x1e\n" +
+	"\n" +
+	"KeyRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"\x9f\x01\n" +
+	"\x06Sketch\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12)\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x15.gocountme.SketchTypeR\x04type\x12\f\n" +
+	"\x01k\x18\x03 \x01(\x05R\x01k\x12\x14\n" +
+	"\x05exact\x18\x04 \x01(\bR\x05exact\x12 \n" +
+	"\vcardinality\x18\x05 \x01(\x01R\vcardinality\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\fR\x04data\"\r\n" +
+	"\vDeleteReply\"\xc2\x01\n" +
+	"\n" +
+	"AddRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\f\n" +
+	"\x01k\x18\x03 \x01(\x05R\x01k\x12)\n" +
+	"\x04type\x18\x04 \x01(\x0e2\x15.gocountme.SketchTypeR\x04type\x12\x1f\n" +
+	"\vttl_seconds\x18\x05 \x01(\x03R\n" +
+	"ttlSeconds\x12\x13\n" +
+	"\x02ts\x18\x06 \x01(\x03H\x00R\x02ts\x88\x01\x01\x12\x16\n" +
+	"\x06bucket\x18\a \x01(\tR\x06bucketB\x05\n" +
+	"\x03_ts\"\xc4\x01\n" +
+	"\x0eAddHashRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\x04R\x04hash\x12\f\n" +
+	"\x01k\x18\x03 \x01(\x05R\x01k\x12)\n" +
+	"\x04type\x18\x04 \x01(\x0e2\x15.gocountme.SketchTypeR\x04type\x12\x1f\n" +
+	"\vttl_seconds\x18\x05 \x01(\x03R\n" +
+	"ttlSeconds\x12\x13\n" +
+	"\x02ts\x18\x06 \x01(\x03H\x00R\x02ts\x88\x01\x01\x12\x16\n" +
+	"\x06bucket\x18\a \x01(\tR\x06bucketB\x05\n" +
+	"\x03_ts\"\x1c\n" +
+	"\bAddReply\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"h\n" +
+	"\x0eAddStreamError\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12&\n" +
+	"\x05error\x18\x02 \x01(\v2\x10.gocountme.ErrorR\x05error\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"q\n" +
+	"\x0eAddStreamReply\x12\x14\n" +
+	"\x05added\x18\x01 \x01(\x03R\x05added\x12\x16\n" +
+	"\x06failed\x18\x02 \x01(\x03R\x06failed\x121\n" +
+	"\x06errors\x18\x03 \x03(\v2\x19.gocountme.AddStreamErrorR\x06errors\"\x88\x01\n" +
+	"\bEstimate\x12\x1a\n" +
+	"\bestimate\x18\x01 \x01(\x01R\bestimate\x12\x14\n" +
+	"\x05lower\x18\x02 \x01(\x01R\x05lower\x12\x14\n" +
+	"\x05upper\x18\x03 \x01(\x01R\x05upper\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x04 \x01(\x01R\n" +
+	"confidence\x12\x14\n" +
+	"\x05exact\x18\x05 \x01(\bR\x05exact\"\x9c\x01\n" +
+	"\x12CardinalityRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x02 \x01(\x01R\n" +
+	"confidence\x12\x17\n" +
+	"\x04from\x18\x03 \x01(\x03H\x00R\x04from\x88\x01\x01\x12\x13\n" +
+	"\x02to\x18\x04 \x01(\x03H\x01R\x02to\x88\x01\x01\x12\x16\n" +
+	"\x06bucket\x18\x05 \x01(\tR\x06bucketB\a\n" +
+	"\x05_fromB\x05\n" +
+	"\x03_to\"X\n" +
+	"\x0eJaccardRequest\x12\x12\n" +
+	"\x04key1\x18\x01 \x01(\tR\x04key1\x12\x12\n" +
+	"\x04key2\x18\x02 \x01(\tR\x04key2\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x03 \x01(\x01R\n" +
+	"confidence\"H\n" +
+	"\x12CorrelationRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x02 \x01(\x01R\n" +
+	"confidence\"k\n" +
+	"\x12CorrelationElement\x12\x12\n" +
+	"\x04key1\x18\x01 \x01(\tR\x04key1\x12\x12\n" +
+	"\x04key2\x18\x02 \x01(\tR\x04key2\x12-\n" +
+	"\ajaccard\x18\x03 \x01(\v2\x13.gocountme.EstimateR\ajaccard\"M\n" +
+	"\x10CorrelationReply\x129\n" +
+	"\belements\x18\x01 \x03(\v2\x1d.gocountme.CorrelationElementR\belements\"D\n" +
+	"\fQueryRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x02 \x01(\x01R\n" +
+	"confidence\"\xb9\x01\n" +
+	"\n" +
+	"QueryReply\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x03set\x18\x02 \x01(\v2\x11.gocountme.SketchR\x03set\x12\x16\n" +
+	"\x06result\x18\x03 \x01(\x01R\x06result\x12/\n" +
+	"\binterval\x18\x04 \x01(\v2\x13.gocountme.EstimateR\binterval\x12+\n" +
+	"\x05multi\x18\x05 \x03(\v2\x15.gocountme.QueryReplyR\x05multi*)\n" +
+	"\n" +
+	"SketchType\x12\a\n" +
+	"\x03KMV\x10\x00\x12\a\n" +
+	"\x03HLL\x10\x01\x12\t\n" +
+	"\x05THETA\x10\x02*\xad\x01\n" +
+	"\tErrorCode\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\x0f\n" +
+	"\vMISSING_KEY\x10\x01\x12\x11\n" +
+	"\rKEY_NOT_FOUND\x10\x02\x12\x14\n" +
+	"\x10INVALID_ARGUMENT\x10\x03\x12\x1b\n" +
+	"\x17UNSUPPORTED_SKETCH_TYPE\x10\x04\x12\x16\n" +
+	"\x12MIXED_SKETCH_TYPES\x10\x05\x12\x11\n" +
+	"\rINVALID_QUERY\x10\x06\x12\x11\n" +
+	"\rSHUTTING_DOWN\x10\a2\xa6\x04\n" +
+	"\tGoCountMe\x12/\n" +
+	"\x03Get\x12\x15.gocountme.KeyRequest\x1a\x11.gocountme.Sketch\x127\n" +
+	"\x06Delete\x12\x15.gocountme.KeyRequest\x1a\x16.gocountme.DeleteReply\x121\n" +
+	"\x03Add\x12\x15.gocountme.AddRequest\x1a\x13.gocountme.AddReply\x129\n" +
+	"\aAddHash\x12\x19.gocountme.AddHashRequest\x1a\x13.gocountme.AddReply\x12?\n" +
+	"\tAddStream\x12\x15.gocountme.AddRequest\x1a\x19.gocountme.AddStreamReply(\x01\x12A\n" +
+	"\vCardinality\x12\x1d.gocountme.CardinalityRequest\x1a\x13.gocountme.Estimate\x129\n" +
+	"\aJaccard\x12\x19.gocountme.JaccardRequest\x1a\x13.gocountme.Estimate\x12I\n" +
+	"\vCorrelation\x12\x1d.gocountme.CorrelationRequest\x1a\x1b.gocountme.CorrelationReply\x127\n" +
+	"\x05Query\x12\x17.gocountme.QueryRequest\x1a\x15.gocountme.QueryReplyB0Z.github.com/mynameisfiber/gocountme/gocountmepbb\x06proto3"
+
+var (
+	file_gocountme_proto_rawDescOnce sync.Once
+	file_gocountme_proto_rawDescData []byte
+)
+
+func file_gocountme_proto_rawDescGZIP() []byte {
+	file_gocountme_proto_rawDescOnce.Do(func() {
+		file_gocountme_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gocountme_proto_rawDesc), len(file_gocountme_proto_rawDesc)))
+	})
+	return file_gocountme_proto_rawDescData
+}
+
+var file_gocountme_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_gocountme_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_gocountme_proto_goTypes = []any{
+	(SketchType)(0),            // 0: gocountme.SketchType
+	(ErrorCode)(0),             // 1: gocountme.ErrorCode
+	(*Error)(nil),              // 2: gocountme.Error
+	(*KeyRequest)(nil),         // 3: gocountme.KeyRequest
+	(*Sketch)(nil),             // 4: gocountme.Sketch
+	(*DeleteReply)(nil),        // 5: gocountme.DeleteReply
+	(*AddRequest)(nil),         // 6: gocountme.AddRequest
+	(*AddHashRequest)(nil),     // 7: gocountme.AddHashRequest
+	(*AddReply)(nil),           // 8: gocountme.AddReply
+	(*AddStreamError)(nil),     // 9: gocountme.AddStreamError
+	(*AddStreamReply)(nil),     // 10: gocountme.AddStreamReply
+	(*Estimate)(nil),           // 11: gocountme.Estimate
+	(*CardinalityRequest)(nil), // 12: gocountme.CardinalityRequest
+	(*JaccardRequest)(nil),     // 13: gocountme.JaccardRequest
+	(*CorrelationRequest)(nil), // 14: gocountme.CorrelationRequest
+	(*CorrelationElement)(nil), // 15: gocountme.CorrelationElement
+	(*CorrelationReply)(nil),   // 16: gocountme.CorrelationReply
+	(*QueryRequest)(nil),       // 17: gocountme.QueryRequest
+	(*QueryReply)(nil),         // 18: gocountme.QueryReply
+}
+var file_gocountme_proto_depIdxs = []int32{
+	1,  // 0: gocountme.Error.code:type_name -> gocountme.ErrorCode
+	0,  // 1: gocountme.Sketch.type:type_name -> gocountme.SketchType
+	0,  // 2: gocountme.AddRequest.type:type_name -> gocountme.SketchType
+	0,  // 3: gocountme.AddHashRequest.type:type_name -> gocountme.SketchType
+	2,  // 4: gocountme.AddStreamError.error:type_name -> gocountme.Error
+	9,  // 5: gocountme.AddStreamReply.errors:type_name -> gocountme.AddStreamError
+	11, // 6: gocountme.CorrelationElement.jaccard:type_name -> gocountme.Estimate
+	15, // 7: gocountme.CorrelationReply.elements:type_name -> gocountme.CorrelationElement
+	4,  // 8: gocountme.QueryReply.set:type_name -> gocountme.Sketch
+	11, // 9: gocountme.QueryReply.interval:type_name -> gocountme.Estimate
+	18, // 10: gocountme.QueryReply.multi:type_name -> gocountme.QueryReply
+	3,  // 11: gocountme.GoCountMe.Get:input_type -> gocountme.KeyRequest
+	3,  // 12: gocountme.GoCountMe.Delete:input_type -> gocountme.KeyRequest
+	6,  // 13: gocountme.GoCountMe.Add:input_type -> gocountme.AddRequest
+	7,  // 14: gocountme.GoCountMe.AddHash:input_type -> gocountme.AddHashRequest
+	6,  // 15: gocountme.GoCountMe.AddStream:input_type -> gocountme.AddRequest
+	12, // 16: gocountme.GoCountMe.Cardinality:input_type -> gocountme.CardinalityRequest
+	13, // 17: gocountme.GoCountMe.Jaccard:input_type -> gocountme.JaccardRequest
+	14, // 18: gocountme.GoCountMe.Correlation:input_type -> gocountme.CorrelationRequest
+	17, // 19: gocountme.GoCountMe.Query:input_type -> gocountme.QueryRequest
+	4,  // 20: gocountme.GoCountMe.Get:output_type -> gocountme.Sketch
+	5,  // 21: gocountme.GoCountMe.Delete:output_type -> gocountme.DeleteReply
+	8,  // 22: gocountme.GoCountMe.Add:output_type -> gocountme.AddReply
+	8,  // 23: gocountme.GoCountMe.AddHash:output_type -> gocountme.AddReply
+	10, // 24: gocountme.GoCountMe.AddStream:output_type -> gocountme.AddStreamReply
+	11, // 25: gocountme.GoCountMe.Cardinality:output_type -> gocountme.Estimate
+	11, // 26: gocountme.GoCountMe.Jaccard:output_type -> gocountme.Estimate
+	16, // 27: gocountme.GoCountMe.Correlation:output_type -> gocountme.CorrelationReply
+	18, // 28: gocountme.GoCountMe.Query:output_type -> gocountme.QueryReply
+	20, // [20:29] is the sub-list for method output_type
+	11, // [11:20] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_gocountme_proto_init() }
+func file_gocountme_proto_init() {
+	if File_gocountme_proto != nil {
+		return
+	}
+	file_gocountme_proto_msgTypes[4].OneofWrappers = []any{}
+	file_gocountme_proto_msgTypes[5].OneofWrappers = []any{}
+	file_gocountme_proto_msgTypes[10].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gocountme_proto_rawDesc), len(file_gocountme_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gocountme_proto_goTypes,
+		DependencyIndexes: file_gocountme_proto_depIdxs,
+		EnumInfos:         file_gocountme_proto_enumTypes,
+		MessageInfos:      file_gocountme_proto_msgTypes,
+	}.Build()
+	File_gocountme_proto = out.File
+	file_gocountme_proto_goTypes = nil
+	file_gocountme_proto_depIdxs = nil
+}
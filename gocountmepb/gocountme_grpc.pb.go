@@ -0,0 +1,429 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: gocountme.proto
+
+package gocountmepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GoCountMe_Get_FullMethodName         = "/gocountme.GoCountMe/Get"
+	GoCountMe_Delete_FullMethodName      = "/gocountme.GoCountMe/Delete"
+	GoCountMe_Add_FullMethodName         = "/gocountme.GoCountMe/Add"
+	GoCountMe_AddHash_FullMethodName     = "/gocountme.GoCountMe/AddHash"
+	GoCountMe_AddStream_FullMethodName   = "/gocountme.GoCountMe/AddStream"
+	GoCountMe_Cardinality_FullMethodName = "/gocountme.GoCountMe/Cardinality"
+	GoCountMe_Jaccard_FullMethodName     = "/gocountme.GoCountMe/Jaccard"
+	GoCountMe_Correlation_FullMethodName = "/gocountme.GoCountMe/Correlation"
+	GoCountMe_Query_FullMethodName       = "/gocountme.GoCountMe/Query"
+)
+
+// GoCountMeClient is the client API for GoCountMe service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The gRPC version of the HTTP API.  Failed calls return a status with one of
+// the standard gRPC codes and an Error detail giving the reason.
+type GoCountMeClient interface {
+	Get(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*Sketch, error)
+	Delete(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*DeleteReply, error)
+	Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*AddReply, error)
+	AddHash(ctx context.Context, in *AddHashRequest, opts ...grpc.CallOption) (*AddReply, error)
+	// Adds every streamed value and replies once the stream is closed.  Values
+	// that can't be added are reported in the reply and don't end the stream.
+	AddStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[AddRequest, AddStreamReply], error)
+	Cardinality(ctx context.Context, in *CardinalityRequest, opts ...grpc.CallOption) (*Estimate, error)
+	Jaccard(ctx context.Context, in *JaccardRequest, opts ...grpc.CallOption) (*Estimate, error)
+	Correlation(ctx context.Context, in *CorrelationRequest, opts ...grpc.CallOption) (*CorrelationReply, error)
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryReply, error)
+}
+
+type goCountMeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGoCountMeClient(cc grpc.ClientConnInterface) GoCountMeClient {
+	return &goCountMeClient{cc}
+}
+
+func (c *goCountMeClient) Get(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*Sketch, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Sketch)
+	err := c.cc.Invoke(ctx, GoCountMe_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goCountMeClient) Delete(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*DeleteReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteReply)
+	err := c.cc.Invoke(ctx, GoCountMe_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goCountMeClient) Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*AddReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddReply)
+	err := c.cc.Invoke(ctx, GoCountMe_Add_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goCountMeClient) AddHash(ctx context.Context, in *AddHashRequest, opts ...grpc.CallOption) (*AddReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddReply)
+	err := c.cc.Invoke(ctx, GoCountMe_AddHash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goCountMeClient) AddStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[AddRequest, AddStreamReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GoCountMe_ServiceDesc.Streams[0], GoCountMe_AddStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AddRequest, AddStreamReply]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoCountMe_AddStreamClient = grpc.ClientStreamingClient[AddRequest, AddStreamReply]
+
+func (c *goCountMeClient) Cardinality(ctx context.Context, in *CardinalityRequest, opts ...grpc.CallOption) (*Estimate, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Estimate)
+	err := c.cc.Invoke(ctx, GoCountMe_Cardinality_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goCountMeClient) Jaccard(ctx context.Context, in *JaccardRequest, opts ...grpc.CallOption) (*Estimate, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Estimate)
+	err := c.cc.Invoke(ctx, GoCountMe_Jaccard_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goCountMeClient) Correlation(ctx context.Context, in *CorrelationRequest, opts ...grpc.CallOption) (*CorrelationReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CorrelationReply)
+	err := c.cc.Invoke(ctx, GoCountMe_Correlation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goCountMeClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryReply)
+	err := c.cc.Invoke(ctx, GoCountMe_Query_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GoCountMeServer is the server API for GoCountMe service.
+// All implementations must embed UnimplementedGoCountMeServer
+// for forward compatibility.
+//
+// The gRPC version of the HTTP API.  Failed calls return a status with one of
+// the standard gRPC codes and an Error detail giving the reason.
+type GoCountMeServer interface {
+	Get(context.Context, *KeyRequest) (*Sketch, error)
+	Delete(context.Context, *KeyRequest) (*DeleteReply, error)
+	Add(context.Context, *AddRequest) (*AddReply, error)
+	AddHash(context.Context, *AddHashRequest) (*AddReply, error)
+	// Adds every streamed value and replies once the stream is closed.  Values
+	// that can't be added are reported in the reply and don't end the stream.
+	AddStream(grpc.ClientStreamingServer[AddRequest, AddStreamReply]) error
+	Cardinality(context.Context, *CardinalityRequest) (*Estimate, error)
+	Jaccard(context.Context, *JaccardRequest) (*Estimate, error)
+	Correlation(context.Context, *CorrelationRequest) (*CorrelationReply, error)
+	Query(context.Context, *QueryRequest) (*QueryReply, error)
+	mustEmbedUnimplementedGoCountMeServer()
+}
+
+// UnimplementedGoCountMeServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGoCountMeServer struct{}
+
+func (UnimplementedGoCountMeServer) Get(context.Context, *KeyRequest) (*Sketch, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedGoCountMeServer) Delete(context.Context, *KeyRequest) (*DeleteReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedGoCountMeServer) Add(context.Context, *AddRequest) (*AddReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
+}
+func (UnimplementedGoCountMeServer) AddHash(context.Context, *AddHashRequest) (*AddReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddHash not implemented")
+}
+func (UnimplementedGoCountMeServer) AddStream(grpc.ClientStreamingServer[AddRequest, AddStreamReply]) error {
+	return status.Errorf(codes.Unimplemented, "method AddStream not implemented")
+}
+func (UnimplementedGoCountMeServer) Cardinality(context.Context, *CardinalityRequest) (*Estimate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cardinality not implemented")
+}
+func (UnimplementedGoCountMeServer) Jaccard(context.Context, *JaccardRequest) (*Estimate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Jaccard not implemented")
+}
+func (UnimplementedGoCountMeServer) Correlation(context.Context, *CorrelationRequest) (*CorrelationReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Correlation not implemented")
+}
+func (UnimplementedGoCountMeServer) Query(context.Context, *QueryRequest) (*QueryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedGoCountMeServer) mustEmbedUnimplementedGoCountMeServer() {}
+func (UnimplementedGoCountMeServer) testEmbeddedByValue()                   {}
+
+// UnsafeGoCountMeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GoCountMeServer will
+// result in compilation errors.
+type UnsafeGoCountMeServer interface {
+	mustEmbedUnimplementedGoCountMeServer()
+}
+
+func RegisterGoCountMeServer(s grpc.ServiceRegistrar, srv GoCountMeServer) {
+	// If the following call pancis, it indicates UnimplementedGoCountMeServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GoCountMe_ServiceDesc, srv)
+}
+
+func _GoCountMe_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoCountMeServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoCountMe_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoCountMeServer).Get(ctx, req.(*KeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoCountMe_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoCountMeServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoCountMe_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoCountMeServer).Delete(ctx, req.(*KeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoCountMe_Add_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoCountMeServer).Add(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoCountMe_Add_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoCountMeServer).Add(ctx, req.(*AddRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoCountMe_AddHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoCountMeServer).AddHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoCountMe_AddHash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoCountMeServer).AddHash(ctx, req.(*AddHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoCountMe_AddStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GoCountMeServer).AddStream(&grpc.GenericServerStream[AddRequest, AddStreamReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GoCountMe_AddStreamServer = grpc.ClientStreamingServer[AddRequest, AddStreamReply]
+
+func _GoCountMe_Cardinality_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CardinalityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoCountMeServer).Cardinality(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoCountMe_Cardinality_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoCountMeServer).Cardinality(ctx, req.(*CardinalityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoCountMe_Jaccard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JaccardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoCountMeServer).Jaccard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoCountMe_Jaccard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoCountMeServer).Jaccard(ctx, req.(*JaccardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoCountMe_Correlation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CorrelationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoCountMeServer).Correlation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoCountMe_Correlation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoCountMeServer).Correlation(ctx, req.(*CorrelationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoCountMe_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoCountMeServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoCountMe_Query_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoCountMeServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GoCountMe_ServiceDesc is the grpc.ServiceDesc for GoCountMe service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GoCountMe_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gocountme.GoCountMe",
+	HandlerType: (*GoCountMeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _GoCountMe_Get_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _GoCountMe_Delete_Handler,
+		},
+		{
+			MethodName: "Add",
+			Handler:    _GoCountMe_Add_Handler,
+		},
+		{
+			MethodName: "AddHash",
+			Handler:    _GoCountMe_AddHash_Handler,
+		},
+		{
+			MethodName: "Cardinality",
+			Handler:    _GoCountMe_Cardinality_Handler,
+		},
+		{
+			MethodName: "Jaccard",
+			Handler:    _GoCountMe_Jaccard_Handler,
+		},
+		{
+			MethodName: "Correlation",
+			Handler:    _GoCountMe_Correlation_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _GoCountMe_Query_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AddStream",
+			Handler:       _GoCountMe_AddStream_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "gocountme.proto",
+}
@@ -0,0 +1,390 @@
+//go:build grpc
+
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/mynameisfiber/gocountme/gocountmepb"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"github.com/mynameisfiber/gocountme/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net"
+	"time"
+)
+
+var grpcAddress = flag.String("grpc", "", "gRPC service address (e.g., ':8082', disabled by default)")
+
+func init() {
+	protocolServers = append(protocolServers, protocolServer{"gRPC", grpcAddress, ServeGRPC})
+}
+
+type grpcErrorCode struct {
+	code   codes.Code
+	reason gocountmepb.ErrorCode
+}
+
+// The gRPC status code and reason given for errors coming from the workers
+var grpcErrors = map[error]grpcErrorCode{
+	store.NoKeySpecified:             {codes.InvalidArgument, gocountmepb.ErrorCode_MISSING_KEY},
+	store.KeyNotFound:                {codes.NotFound, gocountmepb.ErrorCode_KEY_NOT_FOUND},
+	store.InvalidBucket:              {codes.InvalidArgument, gocountmepb.ErrorCode_INVALID_ARGUMENT},
+	store.InvalidRange:               {codes.InvalidArgument, gocountmepb.ErrorCode_INVALID_ARGUMENT},
+	store.TooManyBuckets:             {codes.InvalidArgument, gocountmepb.ErrorCode_INVALID_ARGUMENT},
+	kminvalues.UnsupportedSketchType: {codes.FailedPrecondition, gocountmepb.ErrorCode_UNSUPPORTED_SKETCH_TYPE},
+	kminvalues.MixedSketchTypes:      {codes.FailedPrecondition, gocountmepb.ErrorCode_MIXED_SKETCH_TYPES},
+	ServerShuttingDown:               {codes.Unavailable, gocountmepb.ErrorCode_SHUTTING_DOWN},
+}
+
+// Maximum number of errors that are reported back by AddStream.  Every failed
+// value is still counted in the reply.
+const maxAddStreamErrors = 100
+
+type grpcServer struct {
+	gocountmepb.UnimplementedGoCountMeServer
+}
+
+// Serves the gRPC API on listener until it is closed
+func ServeGRPC(listener net.Listener) error {
+	server := grpc.NewServer(grpc.UnaryInterceptor(grpcShutdownGate))
+	gocountmepb.RegisterGoCountMeServer(server, grpcServer{})
+	return server.Serve(listener)
+}
+
+// The gRPC version of shutdownGate
+func grpcShutdownGate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !beginRequest() {
+		return nil, grpcError(ServerShuttingDown)
+	}
+	defer endRequest()
+	return handler(ctx, req)
+}
+
+func grpcDetail(err error) *gocountmepb.Error {
+	if known, found := grpcErrors[err]; found {
+		return &gocountmepb.Error{Code: known.reason}
+	}
+	return &gocountmepb.Error{Code: gocountmepb.ErrorCode_UNKNOWN}
+}
+
+func grpcStatus(code codes.Code, detail *gocountmepb.Error, message string) error {
+	st := status.New(code, message)
+	if detailed, err := st.WithDetails(detail); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Turns an error from the workers into a status with an Error detail
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := codes.Internal
+	if known, found := grpcErrors[err]; found {
+		code = known.code
+	}
+	return grpcStatus(code, grpcDetail(err), err.Error())
+}
+
+// The gRPC version of HttpError(w, 500, "INVALID_ARG_...")
+func grpcInvalid(argument string) error {
+	detail := &gocountmepb.Error{Code: gocountmepb.ErrorCode_INVALID_ARGUMENT, Argument: argument}
+	return grpcStatus(codes.InvalidArgument, detail, "Invalid argument "+argument)
+}
+
+func grpcConfidence(confidence float64) (float64, error) {
+	if confidence == 0 {
+		return store.DefaultConfidence, nil
+	} else if confidence < 0 || confidence >= 1 {
+		return 0, grpcInvalid("confidence")
+	}
+	return confidence, nil
+}
+
+func grpcSketchType(sketchType gocountmepb.SketchType) (kminvalues.SketchType, error) {
+	switch sketchType {
+	case gocountmepb.SketchType_KMV:
+		return kminvalues.TypeKMV, nil
+	case gocountmepb.SketchType_HLL:
+		return kminvalues.TypeHLL, nil
+	case gocountmepb.SketchType_THETA:
+		return kminvalues.TypeTheta, nil
+	}
+	return 0, grpcInvalid("type")
+}
+
+func sketchProto(key string, sketch kminvalues.Sketch) *gocountmepb.Sketch {
+	return &gocountmepb.Sketch{
+		Key:         key,
+		Type:        gocountmepb.SketchType(sketch.Type()),
+		K:           int32(sketch.MaxSize()),
+		Exact:       sketch.Exact(),
+		Cardinality: sketch.Cardinality(),
+		Data:        sketch.Bytes(),
+	}
+}
+
+func estimateProto(estimate *store.Estimate) *gocountmepb.Estimate {
+	if estimate == nil {
+		return nil
+	}
+	return &gocountmepb.Estimate{
+		Estimate:   estimate.Estimate,
+		Lower:      estimate.Lower,
+		Upper:      estimate.Upper,
+		Confidence: estimate.Confidence,
+		Exact:      estimate.Exact,
+	}
+}
+
+func queryProto(result *store.QueryResult) *gocountmepb.QueryReply {
+	reply := &gocountmepb.QueryReply{
+		Key:      result.Key,
+		Result:   result.Num,
+		Interval: estimateProto(result.Interval),
+	}
+	if result.Kmv != nil {
+		reply.Set = sketchProto(result.Key, result.Kmv)
+	}
+	for _, multi := range result.Multi {
+		reply.Multi = append(reply.Multi, queryProto(multi))
+	}
+	return reply
+}
+
+func grpcGet(key string) (kminvalues.Sketch, error) {
+	resultChan := make(chan store.Result, 1)
+	sketchStore.Do(store.GetRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	})
+	result := <-resultChan
+	return result.Data, result.Error
+}
+
+// Adds hash to key, or to its time bucket when ts is set, and returns the key
+// it was added to.  Errors are already gRPC statuses.
+func grpcAdd(key string, hash uint64, k int32, sketchType gocountmepb.SketchType, ttlSeconds int64, ts *int64, bucket string) (string, error) {
+	if k < 0 {
+		return "", grpcInvalid("k")
+	}
+	kind, err := grpcSketchType(sketchType)
+	if err != nil {
+		return "", err
+	}
+	if ttlSeconds < 0 {
+		return "", grpcInvalid("ttl_seconds")
+	}
+	if ts != nil {
+		if *ts < 0 {
+			return "", grpcInvalid("ts")
+		}
+		if bucket == "" {
+			bucket = *defaultBucket
+		}
+		key, err = store.BucketKey(key, bucket, *ts)
+		if err != nil {
+			return "", grpcInvalid("bucket")
+		}
+	}
+
+	result := addHash(key, hash, int(k), kind, time.Duration(ttlSeconds)*time.Second)
+	return key, grpcError(result.Error)
+}
+
+func (gs grpcServer) Get(ctx context.Context, req *gocountmepb.KeyRequest) (*gocountmepb.Sketch, error) {
+	sketch, err := grpcGet(req.Key)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return sketchProto(req.Key, sketch), nil
+}
+
+func (gs grpcServer) Delete(ctx context.Context, req *gocountmepb.KeyRequest) (*gocountmepb.DeleteReply, error) {
+	resultChan := make(chan store.Result, 1)
+	sketchStore.Do(store.DeleteRequest{
+		Key:        req.Key,
+		ResultChan: resultChan,
+	})
+	result := <-resultChan
+	if result.Error != nil {
+		return nil, grpcError(result.Error)
+	}
+	return &gocountmepb.DeleteReply{}, nil
+}
+
+func (gs grpcServer) Add(ctx context.Context, req *gocountmepb.AddRequest) (*gocountmepb.AddReply, error) {
+	if len(req.Value) == 0 {
+		return nil, grpcInvalid("value")
+	}
+	key, err := grpcAdd(req.Key, store.Hashify(req.Value), req.K, req.Type, req.TtlSeconds, req.Ts, req.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &gocountmepb.AddReply{Key: key}, nil
+}
+
+func (gs grpcServer) AddHash(ctx context.Context, req *gocountmepb.AddHashRequest) (*gocountmepb.AddReply, error) {
+	key, err := grpcAdd(req.Key, req.Hash, req.K, req.Type, req.TtlSeconds, req.Ts, req.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &gocountmepb.AddReply{Key: key}, nil
+}
+
+// Streams aren't covered by grpcShutdownGate so that a long stream doesn't
+// hold up shutting down.  Instead every value is registered as a request of
+// its own and the stream fails once the server is shutting down.
+func (gs grpcServer) AddStream(stream gocountmepb.GoCountMe_AddStreamServer) error {
+	reply := &gocountmepb.AddStreamReply{}
+	for i := int64(0); ; i++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(reply)
+		} else if err != nil {
+			return err
+		}
+
+		if !beginRequest() {
+			return grpcError(ServerShuttingDown)
+		}
+		if len(req.Value) == 0 {
+			err = grpcInvalid("value")
+		} else {
+			_, err = grpcAdd(req.Key, store.Hashify(req.Value), req.K, req.Type, req.TtlSeconds, req.Ts, req.Bucket)
+		}
+		endRequest()
+
+		if err == nil {
+			reply.Added += 1
+			continue
+		}
+		reply.Failed += 1
+		if len(reply.Errors) < maxAddStreamErrors {
+			st := status.Convert(err)
+			addError := &gocountmepb.AddStreamError{Index: i, Message: st.Message()}
+			for _, detail := range st.Details() {
+				if e, ok := detail.(*gocountmepb.Error); ok {
+					addError.Error = e
+				}
+			}
+			reply.Errors = append(reply.Errors, addError)
+		}
+	}
+}
+
+func (gs grpcServer) Cardinality(ctx context.Context, req *gocountmepb.CardinalityRequest) (*gocountmepb.Estimate, error) {
+	confidence, err := grpcConfidence(req.Confidence)
+	if err != nil {
+		return nil, err
+	}
+	if req.Key == "" {
+		return nil, grpcError(store.NoKeySpecified)
+	}
+
+	if req.From == nil {
+		sketch, err := grpcGet(req.Key)
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		return estimateProto(store.SketchEstimate(sketch, confidence)), nil
+	}
+
+	to := time.Now().Unix()
+	if req.To != nil {
+		to = *req.To
+	}
+	bucket := req.Bucket
+	if bucket == "" {
+		bucket = *defaultBucket
+	}
+	union, err := sketchStore.TimeRange(req.Key, bucket, *req.From, to)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return estimateProto(store.SketchEstimate(union, confidence)), nil
+}
+
+func (gs grpcServer) Jaccard(ctx context.Context, req *gocountmepb.JaccardRequest) (*gocountmepb.Estimate, error) {
+	confidence, err := grpcConfidence(req.Confidence)
+	if err != nil {
+		return nil, err
+	}
+	sketch1, err := grpcGet(req.Key1)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	sketch2, err := grpcGet(req.Key2)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	thetas, err := kminvalues.AsThetaSketches(sketch1, sketch2)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return estimateProto(store.JaccardEstimate(confidence, thetas...)), nil
+}
+
+// Like /correlation, keys that don't exist are left out of the matrix
+func (gs grpcServer) Correlation(ctx context.Context, req *gocountmepb.CorrelationRequest) (*gocountmepb.CorrelationReply, error) {
+	confidence, err := grpcConfidence(req.Confidence)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Keys) < 2 {
+		return nil, grpcInvalid("keys")
+	}
+
+	keys := make([]string, 0, len(req.Keys))
+	thetas := make([]*kminvalues.ThetaSketch, 0, len(req.Keys))
+	for _, key := range req.Keys {
+		sketch, err := grpcGet(key)
+		if err == store.KeyNotFound {
+			continue
+		} else if err != nil {
+			return nil, grpcError(err)
+		}
+		theta, err := kminvalues.AsThetaSketches(sketch)
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		keys = append(keys, key)
+		thetas = append(thetas, theta[0])
+	}
+
+	reply := &gocountmepb.CorrelationReply{}
+	for i := 0; i < len(thetas)-1; i++ {
+		for j := i + 1; j < len(thetas); j++ {
+			reply.Elements = append(reply.Elements, &gocountmepb.CorrelationElement{
+				Key1:    keys[i],
+				Key2:    keys[j],
+				Jaccard: estimateProto(store.JaccardEstimate(confidence, thetas[i], thetas[j])),
+			})
+		}
+	}
+	return reply, nil
+}
+
+func (gs grpcServer) Query(ctx context.Context, req *gocountmepb.QueryRequest) (*gocountmepb.QueryReply, error) {
+	confidence, err := grpcConfidence(req.Confidence)
+	if err != nil {
+		return nil, err
+	}
+	if req.Query == "" {
+		return nil, grpcInvalid("query")
+	}
+
+	result, err := sketchStore.QueryConfidence([]byte(req.Query), confidence)
+	if err != nil {
+		if _, found := grpcErrors[err]; found {
+			return nil, grpcError(err)
+		}
+		detail := &gocountmepb.Error{Code: gocountmepb.ErrorCode_INVALID_QUERY}
+		return nil, grpcStatus(codes.InvalidArgument, detail, err.Error())
+	}
+	return queryProto(result), nil
+}
@@ -0,0 +1,178 @@
+//go:build grpc
+
+package main
+
+import (
+	"context"
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/gocountmepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+)
+
+// Starts the gRPC server on an in-memory listener and returns a client for it
+// along with a function that stops both
+func testGRPC(t *testing.T) (gocountmepb.GoCountMeClient, func()) {
+	listener := bufconn.Listen(1 << 20)
+	go ServeGRPC(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Equal(t, err, nil)
+	return gocountmepb.NewGoCountMeClient(conn), func() {
+		conn.Close()
+		listener.Close()
+	}
+}
+
+// Returns the status code and the reason from the Error detail of err
+func grpcReason(err error) (codes.Code, gocountmepb.ErrorCode, string) {
+	st := status.Convert(err)
+	for _, detail := range st.Details() {
+		if e, ok := detail.(*gocountmepb.Error); ok {
+			return st.Code(), e.Code, e.Argument
+		}
+	}
+	return st.Code(), gocountmepb.ErrorCode_UNKNOWN, ""
+}
+
+func TestGRPC(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+	client, stop := testGRPC(t)
+	defer stop()
+	ctx := context.Background()
+
+	keys := []string{"_GOTEST_GRPC1", "_GOTEST_GRPC2"}
+	for i, values := range [][]string{{"a", "b", "c"}, {"b", "c", "d"}} {
+		for _, value := range values {
+			reply, err := client.Add(ctx, &gocountmepb.AddRequest{Key: keys[i], Value: []byte(value), K: 10})
+			assert.Equal(t, err, nil)
+			assert.Equal(t, reply.Key, keys[i])
+		}
+		defer client.Delete(ctx, &gocountmepb.KeyRequest{Key: keys[i]})
+	}
+
+	sketch, err := client.Get(ctx, &gocountmepb.KeyRequest{Key: keys[0]})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sketch.K, int32(10))
+	assert.Equal(t, sketch.Exact, true)
+	assert.Equal(t, sketch.Cardinality, 3.0)
+
+	estimate, err := client.Cardinality(ctx, &gocountmepb.CardinalityRequest{Key: keys[1]})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, estimate.Estimate, 3.0)
+	assert.Equal(t, estimate.Confidence, 0.95)
+
+	estimate, err = client.Jaccard(ctx, &gocountmepb.JaccardRequest{Key1: keys[0], Key2: keys[1]})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, estimate.Estimate, 0.5)
+
+	correlation, err := client.Correlation(ctx, &gocountmepb.CorrelationRequest{Keys: append(keys, "_GOTEST_GRPCMISSING")})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(correlation.Elements), 1)
+	assert.Equal(t, correlation.Elements[0].Jaccard.Estimate, 0.5)
+
+	query, err := client.Query(ctx, &gocountmepb.QueryRequest{Query: `{"method" : "cardinality_union", "keys" : ["_GOTEST_GRPC1", "_GOTEST_GRPC2"]}`})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, query.Result, 4.0)
+
+	_, err = client.Get(ctx, &gocountmepb.KeyRequest{Key: "_GOTEST_GRPCMISSING"})
+	code, reason, _ := grpcReason(err)
+	assert.Equal(t, code, codes.NotFound)
+	assert.Equal(t, reason, gocountmepb.ErrorCode_KEY_NOT_FOUND)
+
+	_, err = client.Cardinality(ctx, &gocountmepb.CardinalityRequest{Key: keys[0], Confidence: 2})
+	code, reason, argument := grpcReason(err)
+	assert.Equal(t, code, codes.InvalidArgument)
+	assert.Equal(t, reason, gocountmepb.ErrorCode_INVALID_ARGUMENT)
+	assert.Equal(t, argument, "confidence")
+
+	_, err = client.Query(ctx, &gocountmepb.QueryRequest{Query: `{"method" : "nope", "keys" : ["_GOTEST_GRPC1"]}`})
+	code, reason, _ = grpcReason(err)
+	assert.Equal(t, code, codes.InvalidArgument)
+	assert.Equal(t, reason, gocountmepb.ErrorCode_INVALID_QUERY)
+}
+
+func TestGRPCTimeRange(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+	client, stop := testGRPC(t)
+	defer stop()
+	ctx := context.Background()
+
+	key := "_GOTEST_GRPCTS"
+	for i, value := range []string{"a", "b", "a"} {
+		ts := int64(60 * i)
+		reply, err := client.Add(ctx, &gocountmepb.AddRequest{Key: key, Value: []byte(value), Ts: &ts, Bucket: "minute"})
+		assert.Equal(t, err, nil)
+		defer client.Delete(ctx, &gocountmepb.KeyRequest{Key: reply.Key})
+	}
+
+	from, to := int64(0), int64(179)
+	estimate, err := client.Cardinality(ctx, &gocountmepb.CardinalityRequest{Key: key, From: &from, To: &to, Bucket: "minute"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, estimate.Estimate, 2.0)
+
+	_, err = client.Cardinality(ctx, &gocountmepb.CardinalityRequest{Key: key, From: &to, To: &from, Bucket: "minute"})
+	code, reason, _ := grpcReason(err)
+	assert.Equal(t, code, codes.InvalidArgument)
+	assert.Equal(t, reason, gocountmepb.ErrorCode_INVALID_ARGUMENT)
+}
+
+func TestGRPCAddStream(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+	client, stop := testGRPC(t)
+	defer stop()
+	ctx := context.Background()
+
+	key := "_GOTEST_GRPCSTREAM"
+	defer client.Delete(ctx, &gocountmepb.KeyRequest{Key: key})
+
+	stream, err := client.AddStream(ctx)
+	assert.Equal(t, err, nil)
+	for _, req := range []*gocountmepb.AddRequest{
+		{Key: key, Value: []byte("a")},
+		{Key: key},
+		{Key: key, Value: []byte("b")},
+		{Value: []byte("c")},
+	} {
+		assert.Equal(t, stream.Send(req), nil)
+	}
+	reply, err := stream.CloseAndRecv()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reply.Added, int64(2))
+	assert.Equal(t, reply.Failed, int64(2))
+	assert.Equal(t, reply.Errors[0].Index, int64(1))
+	assert.Equal(t, reply.Errors[0].Error.Code, gocountmepb.ErrorCode_INVALID_ARGUMENT)
+	assert.Equal(t, reply.Errors[1].Index, int64(3))
+	assert.Equal(t, reply.Errors[1].Error.Code, gocountmepb.ErrorCode_MISSING_KEY)
+
+	estimate, err := client.Cardinality(ctx, &gocountmepb.CardinalityRequest{Key: key})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, estimate.Estimate, 2.0)
+}
+
+func TestGRPCShuttingDown(t *testing.T) {
+	defer func() { shuttingDown = false }()
+	SetupDB()
+	defer CloseDB()
+	client, stop := testGRPC(t)
+	defer stop()
+
+	drainRequests(context.Background())
+	_, err := client.Get(context.Background(), &gocountmepb.KeyRequest{Key: "_GOTEST_GRPC1"})
+	code, reason, _ := grpcReason(err)
+	assert.Equal(t, code, codes.Unavailable)
+	assert.Equal(t, reason, gocountmepb.ErrorCode_SHUTTING_DOWN)
+}
@@ -3,35 +3,61 @@ package main
 import _ "net/http/pprof"
 
 import (
-	"encoding/binary"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"github.com/jmhodges/levigo"
-	"github.com/reusee/mmh3"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"github.com/mynameisfiber/gocountme/store"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
-	"sync"
+	"syscall"
+	"time"
 )
 
-var requestChan chan RequestCommand
+var sketchStore *store.Store
 
 var (
 	VERSION         = "0.2.1"
 	showVersion     = flag.Bool("version", false, "print version string")
 	httpAddress     = flag.String("http", ":8080", "HTTP service address (e.g., ':8080')")
+	tcpAddress      = flag.String("tcp", "", "Binary protocol service address (e.g., ':8081', disabled by default)")
+	respAddress     = flag.String("resp", "", "Redis protocol service address (e.g., ':6379', disabled by default)")
 	nWorkers        = flag.Int("nworkers", 1, "Number of workers interacting with the DB")
 	defaultSize     = flag.Int("default-size", 1024, "Default size for KMin Value sets")
 	leveldbLRUCache = flag.Int("lru-cache", 1<<16, "LRU Cache size for LevelDB")
 	dblocation      = flag.String("db", ".", "Database location")
+	backend         = flag.String("backend", "leveldb", "Storage backend (leveldb, bolt or memory)")
+	ingestBatchSize = flag.Int("ingest-batch", 1000, "Number of lines /ingest writes to the DB at once")
+	shutdownTimeout = flag.Duration("shutdown-timeout", 30*time.Second, "How long to wait for open connections to close on shutdown")
+	cacheSize       = flag.Int("cache-size", 0, "Number of sets to keep in the write-back cache (0 disables the cache)")
+	cacheFlush      = flag.Duration("cache-flush", 10*time.Second, "How often dirty sets in the cache are written to LevelDB")
+	defaultBucket   = flag.String("bucket", "hour", "Default bucket size for timestamped values (minute, hour or day)")
+	retentionConfig = flag.String("retention-config", "", "JSON file mapping key prefixes to how long keys are kept (eg: \"72h\")")
+	compactInterval = flag.Duration("compact-interval", 5*time.Minute, "How often expired keys are deleted and old buckets are merged (0 disables compaction)")
+	rollupMinutes   = flag.Duration("rollup-minutes", 0, "Merge minute buckets this old into hour buckets (0 disables)")
+	rollupHours     = flag.Duration("rollup-hours", 0, "Merge hour buckets this old into day buckets (0 disables)")
+	slidingWindow   = flag.Duration("sliding-window", 24*time.Hour, "Default window for sliding window sets (0 keeps values until they are pushed out)")
+	sizeConfig      = flag.String("size-config", "", "JSON file mapping key prefixes to KMin Value set sizes")
 )
 
 type correlationMatrixElement struct {
-	Keys    [2]string `json:"keys"`
-	Jaccard float64   `json:"jaccard"`
+	Keys    [2]string       `json:"keys"`
+	Jaccard *store.Estimate `json:"jaccard"`
+}
+
+// Estimates are made for a missing key as if it held an empty set
+func emptyIfMissing(result store.Result) store.Result {
+	if result.Error == store.KeyNotFound {
+		result.Error = nil
+	}
+	return result
 }
 
 func GetHandler(w http.ResponseWriter, r *http.Request) {
@@ -47,12 +73,12 @@ func GetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultChan := make(chan Result)
-	getRequest := GetRequest{
+	resultChan := make(chan store.Result)
+	getRequest := store.GetRequest{
 		Key:        key,
 		ResultChan: resultChan,
 	}
-	requestChan <- getRequest
+	sketchStore.Do(getRequest)
 	result := <-resultChan
 	close(resultChan)
 	HttpResponse(w, 200, result)
@@ -71,12 +97,12 @@ func DeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultChan := make(chan Result)
-	deleteRequest := DeleteRequest{
+	resultChan := make(chan store.Result)
+	deleteRequest := store.DeleteRequest{
 		Key:        key,
 		ResultChan: resultChan,
 	}
-	requestChan <- deleteRequest
+	sketchStore.Do(deleteRequest)
 	result := <-resultChan
 	close(resultChan)
 	HttpResponse(w, 200, result)
@@ -95,25 +121,62 @@ func CardinalityHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultChan := make(chan Result)
-	getRequest := GetRequest{
+	confidence, ok := confidenceParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_CONFIDENCE")
+		return
+	}
+
+	if reqParams.Get("from") != "" {
+		timeRangeCardinality(w, reqParams, key, confidence)
+		return
+	}
+
+	resultChan := make(chan store.Result)
+	getRequest := store.GetRequest{
 		Key:        key,
 		ResultChan: resultChan,
 	}
-	requestChan <- getRequest
-	result := <-resultChan
+	sketchStore.Do(getRequest)
+	result := emptyIfMissing(<-resultChan)
 	close(resultChan)
 	if result.Error == nil {
-		card := result.Data.Cardinality()
-		HttpResponse(w, 200, card)
+		HttpResponse(w, 200, store.SketchEstimate(result.Data, confidence))
 	} else {
-		HttpResponse(w, 500, result.Error)
+		HttpResponse(w, 500, result.Error.Error())
 	}
 }
 
-func Hashify(orig []byte) uint64 {
-	h := mmh3.Hash128(orig)
-	return binary.LittleEndian.Uint64(h)
+// Calculates the cardinality of the union of every bucket of key between the
+// `from` and `to` timestamps
+func timeRangeCardinality(w http.ResponseWriter, reqParams url.Values, key string, confidence float64) {
+	from, ok := timeParam(reqParams, "from")
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_FROM")
+		return
+	}
+
+	to := time.Now().Unix()
+	if reqParams.Get("to") != "" {
+		to, ok = timeParam(reqParams, "to")
+		if !ok {
+			HttpError(w, 500, "INVALID_ARG_TO")
+			return
+		}
+	}
+
+	bucket, ok := bucketParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_BUCKET")
+		return
+	}
+
+	union, err := sketchStore.TimeRange(key, bucket, from, to)
+	if err != nil {
+		HttpResponse(w, 500, err.Error())
+		return
+	}
+	HttpResponse(w, 200, store.SketchEstimate(union, confidence))
 }
 
 func AddHandler(w http.ResponseWriter, r *http.Request) {
@@ -135,9 +198,41 @@ func AddHandler(w http.ResponseWriter, r *http.Request) {
 		HttpError(w, 500, "MISSING_ARG_VALUE")
 		return
 	}
-	hash := Hashify([]byte(value))
+	hash := store.Hashify([]byte(value))
 
-	result := addHash(key, hash)
+	size, ok := sizeParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_K")
+		return
+	}
+
+	sketchType, ok := typeParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_TYPE")
+		return
+	}
+
+	ttl, ok := ttlParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_TTL")
+		return
+	}
+
+	if reqParams.Get("ts") != "" {
+		ts, ok := timeParam(reqParams, "ts")
+		if !ok {
+			HttpError(w, 500, "INVALID_ARG_TS")
+			return
+		}
+		bucket, ok := bucketParam(reqParams)
+		if !ok {
+			HttpError(w, 500, "INVALID_ARG_BUCKET")
+			return
+		}
+		key, _ = store.BucketKey(key, bucket, ts)
+	}
+
+	result := addHash(key, hash, size, sketchType, ttl)
 	if result.Error == nil {
 		HttpResponse(w, 200, "OK")
 	} else {
@@ -163,7 +258,15 @@ func AddMultiHandler(w http.ResponseWriter, r *http.Request) {
 		delimiter = ","
 	}
 
+	size, ok := sizeParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_K")
+		return
+	}
+
 	results := make([]MultiResult, len(valuesRaw))
+	items := make([]store.KeyHash, 0, len(valuesRaw))
+	itemIdxs := make([]int, 0, len(valuesRaw))
 	var values []string
 	for i, item := range valuesRaw {
 		values = strings.SplitN(item, delimiter, 2)
@@ -175,23 +278,31 @@ func AddMultiHandler(w http.ResponseWriter, r *http.Request) {
 				"INVALID_VALUE",
 				500,
 			}
+		} else if values[0] == "" {
+			results[i] = MultiResult{
+				values[0],
+				values[1],
+				store.NoKeySpecified.Error(),
+				500,
+			}
 		} else {
-			hash := Hashify([]byte(values[1]))
-			result := addHash(values[0], hash)
-			if result.Error == nil {
-				results[i] = MultiResult{
-					values[0],
-					values[1],
-					"OK",
-					200,
-				}
-			} else {
-				results[i] = MultiResult{
-					values[0],
-					values[1],
-					result.Error.Error(),
-					500,
-				}
+			results[i] = MultiResult{
+				values[0],
+				values[1],
+				"OK",
+				200,
+			}
+			items = append(items, store.KeyHash{Key: values[0], Hash: store.Hashify([]byte(values[1]))})
+			itemIdxs = append(itemIdxs, i)
+		}
+	}
+
+	if len(items) != 0 {
+		result := bulkAdd(items, size)
+		if result.Error != nil {
+			for _, i := range itemIdxs {
+				results[i].Status = result.Error.Error()
+				results[i].Code = 500
 			}
 		}
 	}
@@ -222,7 +333,39 @@ func AddHashHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result := addHash(key, hash)
+	size, ok := sizeParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_K")
+		return
+	}
+
+	sketchType, ok := typeParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_TYPE")
+		return
+	}
+
+	ttl, ok := ttlParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_TTL")
+		return
+	}
+
+	if reqParams.Get("ts") != "" {
+		ts, ok := timeParam(reqParams, "ts")
+		if !ok {
+			HttpError(w, 500, "INVALID_ARG_TS")
+			return
+		}
+		bucket, ok := bucketParam(reqParams)
+		if !ok {
+			HttpError(w, 500, "INVALID_ARG_BUCKET")
+			return
+		}
+		key, _ = store.BucketKey(key, bucket, ts)
+	}
+
+	result := addHash(key, hash, size, sketchType, ttl)
 	if result.Error == nil {
 		HttpResponse(w, 200, "OK")
 	} else {
@@ -230,15 +373,124 @@ func AddHashHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func addHash(key string, hash uint64) Result {
-	resultChan := make(chan Result)
+func ResizeHandler(w http.ResponseWriter, r *http.Request) {
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	key := reqParams.Get("key")
+	if key == "" {
+		HttpError(w, 500, "MISSING_ARG_KEY")
+		return
+	}
+
+	size_raw := reqParams.Get("size")
+	if size_raw == "" {
+		HttpError(w, 500, "MISSING_ARG_SIZE")
+		return
+	}
+	size, err := strconv.Atoi(size_raw)
+	if err != nil || size <= 0 {
+		HttpError(w, 500, "INVALID_ARG_SIZE")
+		return
+	}
+
+	resultChan := make(chan store.Result)
+	resizeRequest := store.ResizeRequest{
+		Key:        key,
+		NewSize:    size,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(resizeRequest)
+	result := <-resultChan
+	close(resultChan)
+	if result.Error == nil {
+		HttpResponse(w, 200, "OK")
+	} else {
+		HttpResponse(w, 500, result.Error.Error())
+	}
+}
+
+// Reads the optional `k` parameter which sets the size of any set that gets
+// created by the request.  A size of 0 means the size should come from the
+// key size config instead.
+func sizeParam(reqParams url.Values) (int, bool) {
+	size_raw := reqParams.Get("k")
+	if size_raw == "" {
+		return 0, true
+	}
+	size, err := strconv.Atoi(size_raw)
+	if err != nil || size <= 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// Reads the optional `type` parameter which sets what kind of sketch a set
+// created by the request is.  Sets are KMinValues by default.
+func typeParam(reqParams url.Values) (kminvalues.SketchType, bool) {
+	type_raw := reqParams.Get("type")
+	if type_raw == "" {
+		return kminvalues.TypeKMV, true
+	}
+	sketchType, err := kminvalues.ParseSketchType(type_raw)
+	return sketchType, err == nil
+}
+
+// Reads the optional `confidence` parameter giving the confidence level of the
+// intervals around estimates
+func confidenceParam(reqParams url.Values) (float64, bool) {
+	confidence_raw := reqParams.Get("confidence")
+	if confidence_raw == "" {
+		return store.DefaultConfidence, true
+	}
+	confidence, err := strconv.ParseFloat(confidence_raw, 64)
+	if err != nil || confidence <= 0 || confidence >= 1 {
+		return 0, false
+	}
+	return confidence, true
+}
+
+// Reads the optional `ttl` parameter, in seconds, which sets how long a set
+// created by the request is kept for
+func ttlParam(reqParams url.Values) (time.Duration, bool) {
+	ttl_raw := reqParams.Get("ttl")
+	if ttl_raw == "" {
+		return 0, true
+	}
+	ttl, err := strconv.ParseInt(ttl_raw, 10, 64)
+	if err != nil || ttl < 0 {
+		return 0, false
+	}
+	return time.Duration(ttl) * time.Second, true
+}
+
+func addHash(key string, hash uint64, size int, sketchType kminvalues.SketchType, ttl time.Duration) store.Result {
+	resultChan := make(chan store.Result)
 	defer close(resultChan)
-	addHashRequest := AddHashRequest{
+	addHashRequest := store.AddHashRequest{
 		Key:        key,
 		Hash:       hash,
+		Size:       size,
+		Type:       sketchType,
+		TTL:        ttl,
 		ResultChan: resultChan,
 	}
-	requestChan <- addHashRequest
+	sketchStore.Do(addHashRequest)
+	return <-resultChan
+}
+
+func bulkAdd(items []store.KeyHash, size int) store.Result {
+	resultChan := make(chan store.Result)
+	defer close(resultChan)
+	bulkAddRequest := store.BulkAddRequest{
+		Items:      items,
+		Size:       size,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(bulkAddRequest)
 	return <-resultChan
 }
 
@@ -266,29 +518,100 @@ func JaccardHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultChan := make(chan Result, 2)
+	confidence, ok := confidenceParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_CONFIDENCE")
+		return
+	}
+
+	resultChan := make(chan store.Result, 2)
 
-	getRequest1 := GetRequest{
+	getRequest1 := store.GetRequest{
 		Key:        key1,
 		ResultChan: resultChan,
 	}
-	requestChan <- getRequest1
-	result1 := <-resultChan
+	sketchStore.Do(getRequest1)
+	result1 := emptyIfMissing(<-resultChan)
 
-	getRequest2 := GetRequest{
+	getRequest2 := store.GetRequest{
 		Key:        key2,
 		ResultChan: resultChan,
 	}
-	requestChan <- getRequest2
-	result2 := <-resultChan
+	sketchStore.Do(getRequest2)
+	result2 := emptyIfMissing(<-resultChan)
 
 	if result1.Error != nil {
 		HttpResponse(w, 500, result1.Error.Error())
 	} else if result2.Error != nil {
 		HttpResponse(w, 500, result2.Error.Error())
 	} else {
-		jac := result1.Data.Jaccard(result2.Data)
-		HttpResponse(w, 200, QueryResult{Num: jac})
+		thetas, err := kminvalues.AsThetaSketches(result1.Data, result2.Data)
+		if err != nil {
+			HttpResponse(w, 500, err.Error())
+			return
+		}
+		HttpResponse(w, 200, store.JaccardEstimate(confidence, thetas...))
+	}
+}
+
+// Gives the fraction of the first key's items that are also in the second
+func ContainmentHandler(w http.ResponseWriter, r *http.Request) {
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	if len(reqParams["key"]) != 2 {
+		HttpError(w, 500, "MUST_PROVIDE_2_KEYS")
+		return
+	}
+
+	key1 := reqParams["key"][0]
+	if key1 == "" {
+		HttpError(w, 500, "MISSING_ARG_KEY")
+		return
+	}
+
+	key2 := reqParams["key"][1]
+	if key2 == "" {
+		HttpError(w, 500, "MISSING_ARG_KEY")
+		return
+	}
+
+	confidence, ok := confidenceParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_CONFIDENCE")
+		return
+	}
+
+	resultChan := make(chan store.Result, 2)
+
+	getRequest1 := store.GetRequest{
+		Key:        key1,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(getRequest1)
+	result1 := emptyIfMissing(<-resultChan)
+
+	getRequest2 := store.GetRequest{
+		Key:        key2,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(getRequest2)
+	result2 := emptyIfMissing(<-resultChan)
+
+	if result1.Error != nil {
+		HttpResponse(w, 500, result1.Error.Error())
+	} else if result2.Error != nil {
+		HttpResponse(w, 500, result2.Error.Error())
+	} else {
+		thetas, err := kminvalues.AsThetaSketches(result1.Data, result2.Data)
+		if err != nil {
+			HttpResponse(w, 500, err.Error())
+			return
+		}
+		HttpResponse(w, 200, store.ContainmentEstimate(confidence, thetas[0], thetas[1]))
 	}
 }
 
@@ -310,32 +633,51 @@ func CorrelationMatrixHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultChan := make(chan Result, N)
+	confidence, ok := confidenceParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_CONFIDENCE")
+		return
+	}
+
+	resultChan := make(chan store.Result, N)
 	defer close(resultChan)
-	kmvs := make([]*Result, N)
+	kmvs := make([]*store.Result, N)
 	for _, key := range reqParams["key"] {
-		getRequest := GetRequest{
+		getRequest := store.GetRequest{
 			Key:        key,
 			ResultChan: resultChan,
 		}
-		requestChan <- getRequest
+		sketchStore.Do(getRequest)
 	}
 
+	var resultErr error
 	for i := 0; i < N; i++ {
-		result := <-resultChan
-		if result.Error != nil {
-			N -= 1
-			i -= 1
-		} else {
-			kmvs[i] = &result
+		result := emptyIfMissing(<-resultChan)
+		if result.Error != nil && resultErr == nil {
+			resultErr = result.Error
+		}
+		kmvs[i] = &result
+	}
+	if resultErr != nil {
+		HttpResponse(w, 500, resultErr.Error())
+		return
+	}
+
+	thetas := make([]*kminvalues.ThetaSketch, N)
+	for i, result := range kmvs {
+		theta, err := kminvalues.AsThetaSketches(result.Data)
+		if err != nil {
+			HttpResponse(w, 500, err.Error())
+			return
 		}
+		thetas[i] = theta[0]
 	}
 
 	matrix := make([]correlationMatrixElement, 0, N*(N-1)/2)
 	for i, r1 := range kmvs[:N-1] {
-		for _, r2 := range kmvs[i+1 : N] {
+		for k, r2 := range kmvs[i+1:] {
 			key := [2]string{r1.Key, r2.Key}
-			j := r1.Data.Jaccard(r2.Data)
+			j := store.JaccardEstimate(confidence, thetas[i], thetas[i+1+k])
 			matrix = append(matrix, correlationMatrixElement{key, j})
 		}
 	}
@@ -343,6 +685,88 @@ func CorrelationMatrixHandler(w http.ResponseWriter, r *http.Request) {
 	HttpResponse(w, 200, matrix)
 }
 
+func ExpireHandler(w http.ResponseWriter, r *http.Request) {
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	key := reqParams.Get("key")
+	if key == "" {
+		HttpError(w, 500, "MISSING_ARG_KEY")
+		return
+	}
+
+	if reqParams.Get("ttl") == "" {
+		HttpError(w, 500, "MISSING_ARG_TTL")
+		return
+	}
+	ttl, ok := ttlParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_TTL")
+		return
+	}
+
+	resultChan := make(chan store.Result)
+	expireRequest := store.ExpireRequest{
+		Key:        key,
+		TTL:        ttl,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(expireRequest)
+	result := <-resultChan
+	close(resultChan)
+	if result.Error == nil {
+		HttpResponse(w, 200, "OK")
+	} else {
+		HttpResponse(w, 500, result.Error.Error())
+	}
+}
+
+func KeysHandler(w http.ResponseWriter, r *http.Request) {
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	limit := 100
+	if limit_raw := reqParams.Get("limit"); limit_raw != "" {
+		limit, err = strconv.Atoi(limit_raw)
+		if err != nil || limit <= 0 {
+			HttpError(w, 500, "INVALID_ARG_LIMIT")
+			return
+		}
+	}
+
+	details := false
+	if details_raw := reqParams.Get("details"); details_raw != "" {
+		details, err = strconv.ParseBool(details_raw)
+		if err != nil {
+			HttpError(w, 500, "INVALID_ARG_DETAILS")
+			return
+		}
+	}
+
+	resultChan := make(chan store.KeysResult)
+	keysRequest := &store.KeysRequest{
+		Prefix:     reqParams.Get("prefix"),
+		Cursor:     reqParams.Get("cursor"),
+		Limit:      limit,
+		Details:    details,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(keysRequest)
+	result := <-resultChan
+	close(resultChan)
+	if result.Error == nil {
+		HttpResponse(w, 200, result)
+	} else {
+		HttpResponse(w, 500, result.Error.Error())
+	}
+}
+
 func QueryHandler(w http.ResponseWriter, r *http.Request) {
 	reqParams, err := url.ParseQuery(r.URL.RawQuery)
 	if err != nil {
@@ -356,15 +780,18 @@ func QueryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := ParseQuery([]byte(query))
+	confidence, ok := confidenceParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_CONFIDENCE")
+		return
+	}
+
+	result, err := sketchStore.QueryConfidence([]byte(query), confidence)
 	if err != nil {
 		HttpResponse(w, 500, err.Error())
 		return
-	} else {
-		HttpResponse(w, 200, result)
-		return
 	}
-	HttpResponse(w, 500, "SERVER_ERROR")
+	HttpResponse(w, 200, result)
 }
 
 func ExitHandler(w http.ResponseWriter, r *http.Request) {
@@ -372,8 +799,58 @@ func ExitHandler(w http.ResponseWriter, r *http.Request) {
 	Exit()
 }
 
-func Exit() {
-	close(requestChan)
+// Adds every endpoint of the HTTP API to mux
+func registerHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/get", GetHandler)
+	mux.HandleFunc("/delete", DeleteHandler)
+	mux.HandleFunc("/cardinality", CardinalityHandler)
+	mux.HandleFunc("/jaccard", JaccardHandler)
+	mux.HandleFunc("/correlation", CorrelationMatrixHandler)
+	mux.HandleFunc("/containment", ContainmentHandler)
+	mux.HandleFunc("/add", AddHandler)
+	mux.HandleFunc("/addmulti", AddMultiHandler)
+	mux.HandleFunc("/addhash", AddHashHandler)
+	mux.HandleFunc("/resize", ResizeHandler)
+	mux.HandleFunc("/ingest", IngestHandler)
+	mux.HandleFunc("/keys", KeysHandler)
+	mux.HandleFunc("/expire", ExpireHandler)
+	mux.HandleFunc("/query", QueryHandler)
+	mux.HandleFunc("/addweighted", AddWeightedHandler)
+	mux.HandleFunc("/deleteweighted", DeleteWeightedHandler)
+	mux.HandleFunc("/sliding/add", SlidingAddHandler)
+	mux.HandleFunc("/sliding/cardinality", SlidingCardinalityHandler)
+	mux.HandleFunc("/sliding/delete", SlidingDeleteHandler)
+	mux.HandleFunc("/exit", ExitHandler)
+}
+
+// A server for another protocol that is started next to the HTTP server when
+// its address is set.  Servers that need extra dependencies add themselves to
+// protocolServers from files with build tags.
+type protocolServer struct {
+	name    string
+	address *string
+	serve   func(net.Listener) error
+}
+
+var protocolServers = []protocolServer{
+	{"TCP", tcpAddress, ServeTCP},
+	{"RESP", respAddress, ServeRESP},
+}
+
+// Serves connections to address until the returned listener is closed
+func listen(name string, address string, serve func(net.Listener) error) net.Listener {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Starting gocountme %s server on %s", name, address)
+	go func() {
+		err := serve(listener)
+		if !errors.Is(err, net.ErrClosed) {
+			log.Fatal(err)
+		}
+	}()
+	return listener
 }
 
 func main() {
@@ -389,6 +866,46 @@ func main() {
 		return
 	}
 
+	options := store.Options{
+		Backend:         *backend,
+		Workers:         *nWorkers,
+		DefaultSize:     *defaultSize,
+		CacheSize:       *cacheSize,
+		CacheFlush:      *cacheFlush,
+		CompactInterval: *compactInterval,
+		RollupMinutes:   *rollupMinutes,
+		RollupHours:     *rollupHours,
+		LevelDBCache:    *leveldbLRUCache,
+		OnCompact: func(stats store.CompactStats) {
+			if stats.Merged > 0 {
+				log.Printf("Merged %d buckets into %d", stats.Merged, stats.Into)
+			}
+		},
+	}
+
+	if *sizeConfig != "" {
+		var err error
+		options.KeySizes, err = store.LoadKeySizes(*sizeConfig)
+		if err != nil {
+			fmt.Println("Could not load size config:", err)
+			return
+		}
+	}
+
+	if *retentionConfig != "" {
+		var err error
+		options.RetentionRules, err = store.LoadRetentionRules(*retentionConfig)
+		if err != nil {
+			fmt.Println("Could not load retention config:", err)
+			return
+		}
+	}
+
+	if _, ok := store.BucketSizes[*defaultBucket]; !ok {
+		fmt.Println("--bucket must be one of minute, hour or day")
+		return
+	}
+
 	if _, err := os.Stat(*dblocation); err != nil {
 		if os.IsNotExist(err) {
 			fmt.Println("Database location does not exist:", *dblocation)
@@ -396,45 +913,64 @@ func main() {
 		}
 	}
 
-	log.Println("Opening levelDB")
-	opts := levigo.NewOptions()
-	opts.SetCache(levigo.NewLRUCache(*leveldbLRUCache))
-	opts.SetCreateIfMissing(true)
-	db, err := levigo.Open(*dblocation, opts)
-	defer db.Close()
-
-	if err != nil {
+	log.Printf("Opening %s database", *backend)
+	if *cacheSize > 0 {
+		log.Printf("Caching up to %d sets", *cacheSize)
+	}
+	log.Printf("Starting %d workers", *nWorkers)
+	var err error
+	sketchStore, err = store.Open(*dblocation, options)
+	if errors.Is(err, store.UnknownBackend) {
+		fmt.Println(err)
+		return
+	} else if err != nil {
 		log.Panicln(err)
 	}
 
-	requestChan = make(chan RequestCommand, *nWorkers)
-	workerWaitGroup := sync.WaitGroup{}
-	log.Printf("Starting %d workers", *nWorkers)
-	for i := 0; i < *nWorkers; i++ {
-		go func(id int) {
-			workerWaitGroup.Add(1)
-			levelDBWorker(db, requestChan)
-			workerWaitGroup.Done()
-		}(i)
-	}
-
-	http.HandleFunc("/get", GetHandler)
-	http.HandleFunc("/delete", DeleteHandler)
-	http.HandleFunc("/cardinality", CardinalityHandler)
-	http.HandleFunc("/jaccard", JaccardHandler)
-	http.HandleFunc("/correlation", CorrelationMatrixHandler)
-	http.HandleFunc("/add", AddHandler)
-	http.HandleFunc("/addmulti", AddMultiHandler)
-	http.HandleFunc("/addhash", AddHashHandler)
-	http.HandleFunc("/query", QueryHandler)
-	http.HandleFunc("/exit", ExitHandler)
+	registerHandlers(http.DefaultServeMux)
 
+	server := &http.Server{
+		Addr:    *httpAddress,
+		Handler: shutdownGate(http.DefaultServeMux),
+	}
 	log.Printf("Starting gocountme HTTP server on %s", *httpAddress)
-	workerWaitGroup.Add(1)
 	go func() {
-		workerWaitGroup.Done()
-		log.Fatal(http.ListenAndServe(*httpAddress, nil))
+		err := server.ListenAndServe()
+		if err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
 	}()
 
-	workerWaitGroup.Wait()
+	listeners := make([]net.Listener, 0, len(protocolServers))
+	for _, server := range protocolServers {
+		if *server.address != "" {
+			listeners = append(listeners, listen(server.name, *server.address, server.serve))
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case sig := <-signals:
+		log.Printf("Got signal %s", sig)
+	case <-exitChan:
+	}
+
+	log.Println("Draining requests")
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if !drainRequests(ctx) {
+		log.Printf("Requests still running after %s, shutting down anyway", *shutdownTimeout)
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Could not shut down HTTP server cleanly: %s", err)
+	}
+	for _, listener := range listeners {
+		listener.Close()
+	}
+
+	log.Println("Stopping workers and closing the database")
+	if err := sketchStore.Close(); err != nil {
+		log.Printf("Could not flush cache: %s", err)
+	}
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/store"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContainmentHandler(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	sets := map[string][]string{
+		"_GOTEST_CONTAINMENT1": {"a", "b"},
+		"_GOTEST_CONTAINMENT2": {"b", "c", "d"},
+	}
+	for key, values := range sets {
+		for _, value := range values {
+			assert.Equal(t, sketchStore.Add(key, value), nil)
+		}
+		defer sketchStore.Delete(key)
+	}
+
+	w := httptest.NewRecorder()
+	ContainmentHandler(w, httptest.NewRequest("GET", "/containment?key=_GOTEST_CONTAINMENT1&key=_GOTEST_CONTAINMENT2&confidence=0.9", nil))
+	var response struct {
+		Data store.Estimate `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, response.Data, store.Estimate{Estimate: 0.5, Lower: 0.5, Upper: 0.5, Confidence: 0.9, Exact: true})
+}
+
+func TestMissingKeyHandlers(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	key := "_GOTEST_MISSINGPRESENT"
+	assert.Equal(t, sketchStore.Add(key, "a"), nil)
+	defer sketchStore.Delete(key)
+
+	w := httptest.NewRecorder()
+	CardinalityHandler(w, httptest.NewRequest("GET", "/cardinality?key=_GOTEST_MISSING1", nil))
+	assert.Equal(t, w.Code, 200)
+	var estimate struct {
+		Data store.Estimate `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &estimate)
+	assert.Equal(t, estimate.Data.Estimate, 0.0)
+
+	w = httptest.NewRecorder()
+	JaccardHandler(w, httptest.NewRequest("GET", "/jaccard?key=_GOTEST_MISSINGPRESENT&key=_GOTEST_MISSING1", nil))
+	assert.Equal(t, w.Code, 200)
+	json.Unmarshal(w.Body.Bytes(), &estimate)
+	assert.Equal(t, estimate.Data.Estimate, 0.0)
+
+	w = httptest.NewRecorder()
+	CorrelationMatrixHandler(w, httptest.NewRequest("GET", "/correlation?key=_GOTEST_MISSING1&key=_GOTEST_MISSING2", nil))
+	assert.Equal(t, w.Code, 200)
+	var matrix struct {
+		Data []struct {
+			Keys [2]string `json:"keys"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &matrix)
+	assert.Equal(t, len(matrix.Data), 1)
+}
+
+func TestAddWeightedInvalidWeight(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	for _, weight := range []string{"NaN", "Inf", "-1", "0"} {
+		w := httptest.NewRecorder()
+		AddWeightedHandler(w, httptest.NewRequest("GET", "/addweighted?key=_GOTEST_WEIGHTEDHTTP&value=a&weight="+weight, nil))
+		assert.Equal(t, w.Code, 400)
+	}
+}
+
+func SetupDB() {
+	sketchStore = store.New(store.NewMemoryBackend(), store.Options{Workers: 1})
+}
+
+func CloseDB() {
+	sketchStore.Close()
+}
@@ -30,7 +30,7 @@ func HttpError(w http.ResponseWriter, statusCode int, statusTxt string) bool {
 	response := HttpResponseJson{StatusCode: statusCode, StatusTxt: statusTxt}
 	j, err := json.Marshal(response)
 	if err != nil {
-		fmt.Fprintf(w, ERROR_RESPONSE)
+		fmt.Fprint(w, ERROR_RESPONSE)
 		log.Printf("Could not format response: %s", err)
 		return false
 	}
@@ -44,7 +44,7 @@ func HttpResponse(w http.ResponseWriter, statusCode int, data interface{}) bool
 	response := HttpResponseJson{StatusCode: statusCode, Data: data}
 	j, err := json.Marshal(response)
 	if err != nil {
-		fmt.Fprintf(w, ERROR_RESPONSE)
+		fmt.Fprint(w, ERROR_RESPONSE)
 		log.Printf("Could not format response: %s", err)
 		return false
 	}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/mynameisfiber/gocountme/store"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+var (
+	InvalidIngestLine = errors.New("Line must be 'key<TAB>value' or a json object with key and value")
+)
+
+// Maximum number of line errors that are reported back by /ingest.  Every
+// failed line is still counted in the summary.
+const maxIngestErrors = 100
+
+// Largest batch /ingest will hold in memory, larger `batch` parameters are
+// lowered to this
+const maxIngestBatch = 100000
+
+type IngestError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type IngestSummary struct {
+	Lines  int           `json:"lines"`
+	Added  int           `json:"added"`
+	Failed int           `json:"failed"`
+	Errors []IngestError `json:"errors"`
+}
+
+type ingestLine struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (is *IngestSummary) fail(line int, err error) {
+	is.Failed += 1
+	if len(is.Errors) < maxIngestErrors {
+		is.Errors = append(is.Errors, IngestError{line, err.Error()})
+	}
+}
+
+func parseIngestLine(line []byte) (string, string, error) {
+	var parsed ingestLine
+	if line[0] == '{' {
+		err := json.Unmarshal(line, &parsed)
+		if err != nil {
+			return "", "", err
+		}
+	} else {
+		parts := bytes.SplitN(line, []byte{'\t'}, 2)
+		if len(parts) != 2 {
+			return "", "", InvalidIngestLine
+		}
+		parsed.Key, parsed.Value = string(parts[0]), string(parts[1])
+	}
+
+	if parsed.Key == "" {
+		return "", "", store.NoKeySpecified
+	} else if parsed.Value == "" {
+		return "", "", InvalidIngestLine
+	}
+	return parsed.Key, parsed.Value, nil
+}
+
+// Reads a request body of newline delimited `key<TAB>value` or
+// `{"key":"...","value":"..."}` lines and adds every value to its key in
+// batches of `batch` lines
+func IngestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		HttpError(w, 405, "METHOD_NOT_ALLOWED")
+		return
+	}
+
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	size, ok := sizeParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_K")
+		return
+	}
+
+	batchSize := *ingestBatchSize
+	if batch_raw := reqParams.Get("batch"); batch_raw != "" {
+		batchSize, err = strconv.Atoi(batch_raw)
+		if err != nil || batchSize <= 0 {
+			HttpError(w, 400, "INVALID_ARG_BATCH")
+			return
+		}
+	}
+	if batchSize > maxIngestBatch {
+		batchSize = maxIngestBatch
+	}
+
+	summary := IngestSummary{Errors: make([]IngestError, 0)}
+	items := make([]store.KeyHash, 0, batchSize)
+	itemLines := make([]int, 0, batchSize)
+	flush := func() {
+		if len(items) == 0 {
+			return
+		}
+		result := bulkAdd(items, size)
+		if result.Error != nil {
+			for _, line := range itemLines {
+				summary.fail(line, result.Error)
+			}
+		} else {
+			summary.Added += len(items)
+		}
+		items = items[:0]
+		itemLines = itemLines[:0]
+	}
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	// errors give the line number in the body, which counts blank lines that
+	// summary.Lines skips
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum += 1
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		summary.Lines += 1
+
+		key, value, err := parseIngestLine(line)
+		if err != nil {
+			summary.fail(lineNum, err)
+			continue
+		}
+		items = append(items, store.KeyHash{Key: key, Hash: store.Hashify([]byte(value))})
+		itemLines = append(itemLines, lineNum)
+		if len(items) >= batchSize {
+			flush()
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		summary.fail(lineNum+1, err)
+		HttpResponse(w, 500, summary)
+		return
+	}
+	HttpResponse(w, 200, summary)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"github.com/mynameisfiber/gocountme/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIngest(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	keys := []string{"_GOTEST_INGEST1", "_GOTEST_INGEST2"}
+	resultChan := make(chan store.Result)
+	clean := func() {
+		for _, key := range keys {
+			sketchStore.Do(store.DeleteRequest{
+				Key:        key,
+				ResultChan: resultChan,
+			})
+			<-resultChan
+		}
+	}
+	clean()
+	defer clean()
+
+	body := strings.Join([]string{
+		"_GOTEST_INGEST1\ta",
+		"_GOTEST_INGEST1\tb",
+		`{"key" : "_GOTEST_INGEST2", "value" : "a"}`,
+		"",
+		"no tab here",
+		`{"key" : "", "value" : "a"}`,
+		"_GOTEST_INGEST2\tc",
+	}, "\n")
+	r := httptest.NewRequest("POST", "/ingest?batch=2", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	IngestHandler(w, r)
+	assert.Equal(t, w.Code, 200)
+
+	var response struct {
+		Data IngestSummary `json:"data"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, err, nil)
+	summary := response.Data
+	assert.Equal(t, summary.Lines, 6)
+	assert.Equal(t, summary.Added, 4)
+	assert.Equal(t, summary.Failed, 2)
+	assert.Equal(t, summary.Errors[0], IngestError{5, InvalidIngestLine.Error()})
+	assert.Equal(t, summary.Errors[1], IngestError{6, store.NoKeySpecified.Error()})
+
+	for _, key := range keys {
+		sketchStore.Do(store.GetRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		})
+		result := <-resultChan
+		assert.Equal(t, result.Data.(*kminvalues.KMinValues).Len(), 2)
+	}
+
+	r = httptest.NewRequest("GET", "/ingest", nil)
+	w = httptest.NewRecorder()
+	IngestHandler(w, r)
+	assert.Equal(t, w.Code, http.StatusMethodNotAllowed)
+
+	for _, batch := range []string{"0", "-1", "a"} {
+		r = httptest.NewRequest("POST", "/ingest?batch="+batch, strings.NewReader(body))
+		w = httptest.NewRecorder()
+		IngestHandler(w, r)
+		assert.Equal(t, w.Code, http.StatusBadRequest)
+	}
+
+	r = httptest.NewRequest("POST", "/ingest?batch=1000000000", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	IngestHandler(w, r)
+	assert.Equal(t, w.Code, 200)
+}
@@ -0,0 +1,196 @@
+package kminvalues
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	minPrecision = 4
+	maxPrecision = 18
+)
+
+// A HyperLogLog only estimates cardinalities but needs a single byte per
+// register where a KMinValues needs 8 bytes per hash for about the same
+// error.  Since it doesn't keep any hashes it can't be intersected with other
+// sets.
+type HyperLogLog struct {
+	p         uint8
+	registers []uint8
+}
+
+// Creates a HyperLogLog with at least size registers.  The number of
+// registers is always a power of two between 16 and 262144.
+func NewHyperLogLog(size int) *HyperLogLog {
+	p := uint8(minPrecision)
+	for p < maxPrecision && 1<<p < size {
+		p++
+	}
+	return &HyperLogLog{
+		p:         p,
+		registers: make([]uint8, 1<<p),
+	}
+}
+
+func HyperLogLogFromBytes(raw []byte) (*HyperLogLog, error) {
+	if len(raw) < 2 || SketchType(raw[0]) != TypeHLL {
+		return nil, errors.New("error reading data")
+	}
+	p := raw[1]
+	if p < minPrecision || p > maxPrecision || len(raw) != 2+1<<p {
+		return nil, errors.New("error reading size")
+	}
+	return &HyperLogLog{
+		p:         p,
+		registers: raw[2:],
+	}, nil
+}
+
+func (hll *HyperLogLog) Bytes() []byte {
+	result := make([]byte, 2, 2+len(hll.registers))
+	result[0] = byte(TypeHLL)
+	result[1] = hll.p
+	return append(result, hll.registers...)
+}
+
+func (hll *HyperLogLog) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, `{"type":"hll", "m":%d, "data":[`, len(hll.registers))
+	for i, register := range hll.registers {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		fmt.Fprintf(&buffer, "%d", register)
+	}
+	buffer.WriteString("]}")
+	return buffer.Bytes(), nil
+}
+
+// Returns a copy of the HyperLogLog that doesn't share any memory with the
+// original
+func (hll *HyperLogLog) Copy() *HyperLogLog {
+	registers := make([]uint8, len(hll.registers))
+	copy(registers, hll.registers)
+	return &HyperLogLog{
+		p:         hll.p,
+		registers: registers,
+	}
+}
+
+func (hll *HyperLogLog) Type() SketchType { return TypeHLL }
+
+// HyperLogLogs only ever estimate
+func (hll *HyperLogLog) Exact() bool { return false }
+
+// The number of registers
+func (hll *HyperLogLog) MaxSize() int { return len(hll.registers) }
+
+// Adds the hash to the HyperLogLog and returns whether this changed it
+func (hll *HyperLogLog) AddHash(hash uint64) bool {
+	idx := hash >> (64 - hll.p)
+	rho := uint8(bits.LeadingZeros64(hash<<hll.p)) + 1
+	if max := 64 - hll.p + 1; rho > max {
+		rho = max
+	}
+	if rho <= hll.registers[idx] {
+		return false
+	}
+	hll.registers[idx] = rho
+	return true
+}
+
+func (hll *HyperLogLog) Cardinality() float64 {
+	m := float64(len(hll.registers))
+	sum := 0.0
+	zeros := 0
+	for _, register := range hll.registers {
+		sum += math.Ldexp(1, -int(register))
+		if register == 0 {
+			zeros++
+		}
+	}
+
+	estimate := hll.alpha() * m * m / sum
+	// Small cardinalities are better estimated by how many registers are
+	// still empty
+	if estimate <= 2.5*m && zeros > 0 {
+		return m * math.Log(m/float64(zeros))
+	}
+	return estimate
+}
+
+func (hll *HyperLogLog) alpha() float64 {
+	switch len(hll.registers) {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(len(hll.registers)))
+}
+
+func (hll *HyperLogLog) RelativeError() float64 {
+	return 1.04 / math.Sqrt(float64(len(hll.registers)))
+}
+
+// Returns an interval around Cardinality that is numStdDev standard
+// deviations wide on either side
+func (hll *HyperLogLog) Bounds(numStdDev float64) (float64, float64) {
+	estimate := hll.Cardinality()
+	delta := numStdDev * hll.RelativeError() * estimate
+	return math.Max(estimate-delta, 0), estimate + delta
+}
+
+// Returns the HyperLogLog of the union of all the sets.  The result has as
+// many registers as the smallest set.
+func (hll *HyperLogLog) Union(others ...*HyperLogLog) *HyperLogLog {
+	p := hll.p
+	for _, other := range others {
+		if other.p < p {
+			p = other.p
+		}
+	}
+
+	union := hll.fold(p)
+	for _, other := range others {
+		for i, register := range other.fold(p).registers {
+			if register > union.registers[i] {
+				union.registers[i] = register
+			}
+		}
+	}
+	return union
+}
+
+// Returns a copy of the HyperLogLog with 2^p registers, which can't be more
+// than it has already.  The bits of the register index that get dropped are
+// the leading bits of what is left of the hash with fewer registers.
+func (hll *HyperLogLog) fold(p uint8) *HyperLogLog {
+	if p == hll.p {
+		return hll.Copy()
+	}
+	d := hll.p - p
+	folded := &HyperLogLog{
+		p:         p,
+		registers: make([]uint8, 1<<p),
+	}
+	for j, register := range hll.registers {
+		if register == 0 {
+			continue
+		}
+		dropped := uint64(j) & (1<<d - 1)
+		rho := d + register
+		if dropped != 0 {
+			rho = d - uint8(bits.Len64(dropped)) + 1
+		}
+		if idx := j >> d; rho > folded.registers[idx] {
+			folded.registers[idx] = rho
+		}
+	}
+	return folded
+}
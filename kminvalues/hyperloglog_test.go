@@ -0,0 +1,71 @@
+package kminvalues
+
+import (
+	"fmt"
+	"github.com/bmizerany/assert"
+	"math"
+	"testing"
+)
+
+func TestHyperLogLogCardinality(t *testing.T) {
+	hll := NewHyperLogLog(1000)
+	assert.Equal(t, hll.MaxSize(), 1024)
+
+	for _, n := range []int{100, 50000} {
+		hll := NewHyperLogLog(1024)
+		for i := 0; i < n; i++ {
+			hll.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))))
+		}
+		card := hll.Cardinality()
+		relError := math.Abs(card-float64(n)) / float64(n)
+		theoryError := 3 * hll.RelativeError()
+		if relError > theoryError {
+			t.Errorf("Relative error too high: %f instead of %f (ie: %f instead of %d)", relError, theoryError, card, n)
+			t.FailNow()
+		}
+	}
+}
+
+func TestHyperLogLogUnion(t *testing.T) {
+	hll1 := NewHyperLogLog(1024)
+	hll2 := NewHyperLogLog(4096)
+	whole := NewHyperLogLog(1024)
+	for i := 0; i < 20000; i++ {
+		hash := GetHash([]byte(fmt.Sprintf("%d", i)))
+		if i < 15000 {
+			hll1.AddHash(hash)
+		}
+		if i >= 5000 {
+			hll2.AddHash(hash)
+		}
+		whole.AddHash(hash)
+	}
+
+	union := hll1.Union(hll2)
+	assert.Equal(t, union.MaxSize(), 1024)
+	assert.Equal(t, union.registers, whole.registers)
+}
+
+func TestSketchBytes(t *testing.T) {
+	kmv := NewKMinValues(10)
+	kmv.AddHash(1)
+	hll := NewHyperLogLog(16)
+	hll.AddHash(1 << 63)
+
+	for _, sketch := range []Sketch{kmv, hll} {
+		read, err := SketchFromBytes(sketch.Bytes())
+		assert.Equal(t, err, nil)
+		assert.Equal(t, read.Type(), sketch.Type())
+		assert.Equal(t, read.Cardinality(), sketch.Cardinality())
+	}
+
+	_, err := UnionSketches(kmv, hll)
+	assert.Equal(t, err, MixedSketchTypes)
+	union, err := UnionSketches(NewKMinValues(10), hll)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, union.Type(), TypeHLL)
+	_, err = AsKMinValues(kmv, hll)
+	assert.Equal(t, err, UnsupportedSketchType)
+	_, err = SketchFromBytes([]byte{7, 0})
+	assert.Equal(t, err, InvalidSketchType)
+}
@@ -12,6 +12,10 @@ import (
 const bytesUint64 = 8
 const hashMax = float64(1<<64 - 1)
 
+// How many hashes a new KMinValues has room for.  Sets grow as hashes are
+// added so that small sets don't take up the space of a full one.
+const initialCapacity = 16
+
 func hashUint64ToBytes(hash uint64) []byte {
 	hashBytes := new(bytes.Buffer)
 	binary.Write(hashBytes, binary.BigEndian, hash)
@@ -27,46 +31,44 @@ func hashBytesToUint64(hashBytes []byte) uint64 {
 }
 
 func Union(others ...*KMinValues) *KMinValues {
-	maxsize := smallestK(others...)
-	maxlen := 0
+	maxsize := unionK(others...)
 	idxs := make([]int, len(others))
 	for i, other := range others {
-		if maxlen < other.Len() {
-			maxlen = other.Len()
-		}
 		idxs[i] = other.Len() - 1
 	}
 
-	// We directly create a kminvalues object here so that we can have raw be
-	// pre-initialized with nil values
-	newkmv := &KMinValues{
-		raw:     make([]byte, maxlen*bytesUint64, maxsize*bytesUint64),
-		maxSize: maxsize,
-	}
-
+	// We merge the hashes starting from the smallest ones (which live at the
+	// end of raw) until we either fill up the new set or run out of hashes
+	hashes := make([][]byte, 0, maxsize)
 	var kmin, kminTmp []byte
-	jmin := make([]int, 0, len(others))
-	for i := maxlen - 1; i >= 0; i-- {
+	for len(hashes) < maxsize {
 		kmin = nil
-		jmin = jmin[:0]
 		for j, other := range others {
 			kminTmp = other.getHashBytes(idxs[j])
-			if kminTmp != nil {
-				if kmin == nil || kminTmp != nil && bytes.Compare(kmin, kminTmp) > 0 {
-					kmin = kminTmp
-					jmin = jmin[:0]
-					jmin = append(jmin, j)
-				} else if kmin != nil && bytes.Equal(kmin, kminTmp) {
-					jmin = append(jmin, j)
-				}
+			if kminTmp != nil && (kmin == nil || bytes.Compare(kmin, kminTmp) > 0) {
+				kmin = kminTmp
 			}
 		}
-		for _, j := range jmin {
-			idxs[j]--
+		if kmin == nil {
+			break
 		}
-		if kmin != nil {
-			newkmv.SetHash(i, kmin)
+		for j, other := range others {
+			if bytes.Equal(other.getHashBytes(idxs[j]), kmin) {
+				idxs[j]--
+			}
 		}
+		hashes = append(hashes, kmin)
+	}
+
+	// We directly create a kminvalues object here so that we can have raw be
+	// pre-initialized with nil values
+	N := len(hashes)
+	newkmv := &KMinValues{
+		raw:     make([]byte, N*bytesUint64),
+		maxSize: maxsize,
+	}
+	for i, hash := range hashes {
+		newkmv.SetHash(N-1-i, hash)
 	}
 	return newkmv
 }
@@ -75,38 +77,58 @@ func cardinality(maxSize int, kMin uint64) float64 {
 	return float64(maxSize-1.0) * hashMax / float64(kMin)
 }
 
-func smallestK(others ...*KMinValues) int {
-	minsize := others[0].maxSize
-	for _, other := range others[1:] {
-		if minsize > other.maxSize {
-			minsize = other.maxSize
+// The size of the union of the sets.  A set that isn't full holds every one of
+// its hashes, so it doesn't limit how many of the union's smallest hashes are
+// known.  The union is as large as the smallest full set or, when every set is
+// exact, as the largest set.
+func unionK(others ...*KMinValues) int {
+	k, exactK := 0, 0
+	for _, other := range others {
+		if other.Exact() {
+			if other.maxSize > exactK {
+				exactK = other.maxSize
+			}
+		} else if k == 0 || other.maxSize < k {
+			k = other.maxSize
 		}
 	}
-	return minsize
+	if k == 0 {
+		return exactK
+	}
+	return k
 }
 
 type KMinValues struct {
 	raw     []byte
 	maxSize int
+
+	// Set on the result of an intersection or a difference of full sets to
+	// the largest hash of their union, see subset.  Bytes doesn't keep it,
+	// so these results can't be stored.
+	threshold uint64
 }
 
 func (kmv *KMinValues) MarshalJSON() ([]byte, error) {
 	var buffer bytes.Buffer
 	N := kmv.Len()
-	fmt.Fprintf(&buffer, `{"k":%d, "data":[`, kmv.maxSize)
+	fmt.Fprintf(&buffer, `{"k":%d, "exact":%t, "data":[`, kmv.maxSize, kmv.Exact())
 	for n := 0; n < N; n++ {
-		if n == N-1 {
-			fmt.Fprintf(&buffer, "%d]}", kmv.GetHash(n))
-		} else {
-			fmt.Fprintf(&buffer, "%d,", kmv.GetHash(n))
+		if n > 0 {
+			buffer.WriteByte(',')
 		}
+		fmt.Fprintf(&buffer, "%d", kmv.GetHash(n))
 	}
+	buffer.WriteString("]}")
 	return buffer.Bytes(), nil
 }
 
 func NewKMinValues(capacity int) *KMinValues {
+	size := capacity
+	if size > initialCapacity {
+		size = initialCapacity
+	}
 	return &KMinValues{
-		raw:     make([]byte, 0, capacity*bytesUint64),
+		raw:     make([]byte, 0, size*bytesUint64),
 		maxSize: capacity,
 	}
 }
@@ -151,6 +173,26 @@ func (kmv *KMinValues) Bytes() []byte {
 	return result
 }
 
+// Returns a copy of the KMinValues that doesn't share any memory with the
+// original
+func (kmv *KMinValues) Copy() *KMinValues {
+	raw := make([]byte, len(kmv.raw))
+	copy(raw, kmv.raw)
+	return &KMinValues{
+		raw:       raw,
+		maxSize:   kmv.maxSize,
+		threshold: kmv.threshold,
+	}
+}
+
+func (kmv *KMinValues) MaxSize() int { return kmv.maxSize }
+
+func (kmv *KMinValues) Type() SketchType { return TypeKMV }
+
+// A KMinValues that isn't full holds the hash of every item added to it, so
+// its cardinality and any set operations with other exact sets are exact
+func (kmv *KMinValues) Exact() bool { return kmv.threshold == 0 && kmv.Len() < kmv.maxSize }
+
 func (kmv *KMinValues) Len() int { return len(kmv.raw) / bytesUint64 }
 
 func (kmv *KMinValues) SetHash(i int, hash []byte) {
@@ -217,8 +259,8 @@ func (kmv *KMinValues) AddHashBytes(hash []byte) bool {
 	} else {
 		idx, found := kmv.LocateHashBytes(hash)
 		if !found {
-			if cap(kmv.raw) == len(kmv.raw)+1 {
-				kmv.increaseCapacity(len(kmv.raw) * 2)
+			if cap(kmv.raw) == len(kmv.raw) {
+				kmv.increaseCapacity(2*len(kmv.raw) + bytesUint64)
 			}
 			kmv.insert(idx, hash)
 		} else {
@@ -246,17 +288,41 @@ func (kmv *KMinValues) increaseCapacity(newcap int) error {
 	return nil
 }
 
+// Shrinks the KMV down to a maximum size of newSize by only keeping the
+// newSize smallest hashes.  We can't grow a KMV since the hashes that would
+// fill the extra space have already been thrown away.
+func (kmv *KMinValues) Resize(newSize int) error {
+	if newSize <= 0 {
+		return errors.New("size must be greater than 0")
+	}
+	if newSize > kmv.maxSize {
+		return errors.New("cannot increase the size of a KMinValues")
+	}
+	if n := kmv.Len(); n > newSize {
+		newraw := make([]byte, newSize*bytesUint64)
+		copy(newraw, kmv.raw[(n-newSize)*bytesUint64:])
+		kmv.raw = newraw
+	}
+	kmv.maxSize = newSize
+	return nil
+}
+
 func (kmv *KMinValues) Cardinality() float64 {
-	if kmv.Len() < kmv.maxSize {
+	if kmv.Exact() {
 		return float64(kmv.Len())
 	}
+	if kmv.threshold != 0 {
+		// The fraction of the union's k hashes that made it into the subset
+		// times the union's cardinality
+		return float64(kmv.Len()) / float64(kmv.maxSize) * cardinality(kmv.maxSize, kmv.threshold)
+	}
 	return cardinality(kmv.maxSize, kmv.GetHash(0))
 }
 
+// Estimates the size of the intersection by counting the hashes that every
+// set has below their smallest theta
 func (kmv *KMinValues) CardinalityIntersection(others ...*KMinValues) float64 {
-	X, n := DirectSum(append(others, kmv)...)
-	return float64(n) / float64(X.maxSize) * X.Cardinality()
-
+	return ThetaIntersection(thetaSketches(append(others, kmv))...).Cardinality()
 }
 
 func (kmv *KMinValues) CardinalityUnion(others ...*KMinValues) float64 {
@@ -266,8 +332,24 @@ func (kmv *KMinValues) CardinalityUnion(others ...*KMinValues) float64 {
 }
 
 func (kmv *KMinValues) Jaccard(others ...*KMinValues) float64 {
-	X, n := DirectSum(append(others, kmv)...)
-	return float64(n) / float64(X.maxSize)
+	return ThetaJaccard(thetaSketches(append(others, kmv))...)
+}
+
+// Estimates the fraction of the items in the set that are also in other, ie:
+// |A n B| / |A|.  This is the fraction of the set's hashes below both sets'
+// thresholds that other has too, so its error grows as fewer of the set's
+// hashes are below other's threshold, which happens when other is much larger
+// than the set.
+func (kmv *KMinValues) Containment(other *KMinValues) float64 {
+	return ThetaContainment(kmv.Theta(), other.Theta())
+}
+
+func thetaSketches(kmvs []*KMinValues) []*ThetaSketch {
+	thetas := make([]*ThetaSketch, len(kmvs))
+	for i, kmv := range kmvs {
+		thetas[i] = kmv.Theta()
+	}
+	return thetas
 }
 
 // Returns a new KMinValues object is the union between the current and the
@@ -276,27 +358,100 @@ func (kmv *KMinValues) Union(others ...*KMinValues) *KMinValues {
 	return Union(append(others, kmv)...)
 }
 
+// The standard error of Cardinality relative to it.  The estimate's variance
+// is only finite for k of 3 or more, so a full set any smaller than that is
+// only known to within its own size.
 func (kmv *KMinValues) RelativeError() float64 {
+	if kmv.threshold != 0 {
+		return kmv.Theta().RelativeError()
+	}
+	if kmv.maxSize < 3 {
+		if kmv.Exact() {
+			return 0
+		}
+		return 1
+	}
 	return math.Sqrt(2.0 / (math.Pi * float64(kmv.maxSize-2)))
 }
 
-func DirectSum(others ...*KMinValues) (*KMinValues, int) {
-	n := 0
+// Returns an interval around Cardinality that is numStdDev standard
+// deviations wide on either side
+func (kmv *KMinValues) Bounds(numStdDev float64) (float64, float64) {
+	return kmv.Theta().Bounds(numStdDev)
+}
+
+// Returns a new KMinValues object that estimates the intersection between
+// the current and the given objects
+func (kmv *KMinValues) Intersection(others ...*KMinValues) *KMinValues {
+	return Intersection(append(others, kmv)...)
+}
+
+// Returns a new KMinValues object that estimates the set difference between
+// the current object and the union of the given objects
+func (kmv *KMinValues) Difference(others ...*KMinValues) *KMinValues {
+	return Difference(append([]*KMinValues{kmv}, others...)...)
+}
+
+func Intersection(others ...*KMinValues) *KMinValues {
 	X := Union(others...)
+	return intersection(X, others...)
+}
+
+// Returns the difference between the first object and the union of the rest
+func Difference(others ...*KMinValues) *KMinValues {
+	X := Union(others...)
+	return subset(X, func(xHash []byte) bool {
+		if others[0].FindHashBytes(xHash) < 0 {
+			return false
+		}
+		for _, other := range others[1:] {
+			if other.FindHashBytes(xHash) >= 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func intersection(X *KMinValues, others ...*KMinValues) *KMinValues {
 	// TODO: can we optimize this loop somehow?
-	var found bool
-	for i := 0; i < X.Len(); i++ {
-		xHash := X.getHashBytes(i)
-		found = true
+	return subset(X, func(xHash []byte) bool {
 		for _, other := range others {
 			if other.FindHashBytes(xHash) < 0 {
-				found = false
-				break
+				return false
 			}
 		}
-		if found {
-			n += 1
+		return true
+	})
+}
+
+// Creates a new KMinValues out of the hashes in X that pass the keep filter.
+// Since X holds the k smallest hashes of the union, every hash of a subset of
+// the union that is smaller than X's largest hash ends up in the result.  When
+// X is full the result keeps X's k and largest hash, so that its cardinality
+// is the fraction of X's hashes that were kept times X's cardinality.
+// Shrinking k to the number of hashes kept instead would make the estimate
+// meaningless when only a few of them are.
+func subset(X *KMinValues, keep func([]byte) bool) *KMinValues {
+	raw := make([]byte, 0, len(X.raw))
+	for i := 0; i < X.Len(); i++ {
+		xHash := X.getHashBytes(i)
+		if keep(xHash) {
+			raw = append(raw, xHash...)
 		}
 	}
-	return X, n
+
+	result := &KMinValues{
+		raw:     raw,
+		maxSize: X.maxSize,
+	}
+	if !X.Exact() {
+		result.threshold = X.GetHash(0)
+	}
+	return result
+}
+
+func DirectSum(others ...*KMinValues) (*KMinValues, int) {
+	X := Union(others...)
+	return X, intersection(X, others...).Len()
 }
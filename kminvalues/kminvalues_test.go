@@ -14,6 +14,12 @@ func TestKMinValuesConstruct(t *testing.T) {
 	kmv := NewKMinValues(50)
 	assert.Equal(t, kmv.maxSize, 50)
 	assert.Equal(t, len(kmv.raw), 0)
+	assert.Equal(t, cap(kmv.raw), initialCapacity*bytesUint64)
+
+	for i := 0; i < 100; i++ {
+		kmv.AddHash(uint64(i))
+	}
+	assert.Equal(t, kmv.Len(), 50)
 	assert.Equal(t, cap(kmv.raw), 50*bytesUint64)
 }
 
@@ -215,3 +221,264 @@ func TestKMinValuesJaccard(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestKMinValuesResize(t *testing.T) {
+	kmv := NewKMinValues(1000)
+
+	for i := 0; i < 5000; i++ {
+		hash := GetHash([]byte(fmt.Sprintf("%d", i)))
+		kmv.AddHash(hash)
+	}
+
+	smallest := make([]uint64, 100)
+	for i := 0; i < 100; i++ {
+		smallest[i] = kmv.GetHash(kmv.Len() - 100 + i)
+	}
+
+	err := kmv.Resize(100)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, kmv.maxSize, 100)
+	assert.Equal(t, kmv.Len(), 100)
+	for i, hash := range smallest {
+		assert.Equal(t, kmv.GetHash(i), hash)
+	}
+
+	card := kmv.Cardinality()
+	relError := math.Abs(card-5000.0) / 5000.0
+	theoryError := 2 * kmv.RelativeError()
+	if relError > theoryError {
+		t.Errorf("Relative error too high after resize: %f instead of %f", relError, theoryError)
+		t.FailNow()
+	}
+
+	err = kmv.Resize(200)
+	assert.NotEqual(t, err, nil)
+	err = kmv.Resize(0)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestKMinValuesIntersection(t *testing.T) {
+	kmv1 := NewKMinValues(1000)
+	kmv2 := NewKMinValues(1000)
+
+	for i := 0; i < 4000; i++ {
+		hash := GetHash([]byte(fmt.Sprintf("%d", i)))
+		kmv1.AddHash(hash)
+	}
+	for i := 1000; i < 5000; i++ {
+		hash := GetHash([]byte(fmt.Sprintf("%d", i)))
+		kmv2.AddHash(hash)
+	}
+
+	intersection := kmv1.Intersection(kmv2)
+	card := intersection.Cardinality()
+	relError := math.Abs(card-3000.0) / 3000.0
+	theoryError := 2 * intersection.RelativeError()
+	if relError > theoryError {
+		t.Errorf("Relative error too high: %f instead of %f (ie: %f instead of %f)", relError, theoryError, card, 3000.)
+		t.FailNow()
+	}
+
+	for i := 1; i < intersection.Len(); i++ {
+		if intersection.GetHash(i-1) <= intersection.GetHash(i) {
+			t.Errorf("Intersection is not sorted on index %d", i)
+			t.FailNow()
+		}
+	}
+}
+
+func TestKMinValuesIntersectionSmall(t *testing.T) {
+	kmv1 := NewKMinValues(10)
+	kmv2 := NewKMinValues(10)
+
+	for _, hash := range []uint64{1, 2, 3, 4} {
+		kmv1.AddHash(hash)
+	}
+	for _, hash := range []uint64{3, 4, 5, 6} {
+		kmv2.AddHash(hash)
+	}
+
+	intersection := kmv1.Intersection(kmv2)
+	assert.Equal(t, intersection.Cardinality(), 2.0)
+	assert.Equal(t, intersection.GetHash(0), uint64(4))
+	assert.Equal(t, intersection.GetHash(1), uint64(3))
+
+	difference := kmv1.Difference(kmv2)
+	assert.Equal(t, difference.Cardinality(), 2.0)
+	assert.Equal(t, difference.GetHash(0), uint64(2))
+	assert.Equal(t, difference.GetHash(1), uint64(1))
+
+	kmv3 := NewKMinValues(10)
+	for _, hash := range []uint64{0, 7} {
+		kmv3.AddHash(hash)
+	}
+	assert.Equal(t, kmv1.Union(kmv2, kmv3).Cardinality(), 8.0)
+	assert.Equal(t, kmv1.Intersection(kmv3).Cardinality(), 0.0)
+
+	kmv4 := NewKMinValues(10)
+	for _, hash := range []uint64{1, 2, 5, 6} {
+		kmv4.AddHash(hash)
+	}
+	assert.Equal(t, kmv2.Intersection(kmv4).Cardinality(), 2.0)
+}
+
+func TestKMinValuesDifference(t *testing.T) {
+	kmv1 := NewKMinValues(1000)
+	kmv2 := NewKMinValues(1000)
+
+	for i := 0; i < 4000; i++ {
+		hash := GetHash([]byte(fmt.Sprintf("%d", i)))
+		kmv1.AddHash(hash)
+	}
+	for i := 1000; i < 5000; i++ {
+		hash := GetHash([]byte(fmt.Sprintf("%d", i)))
+		kmv2.AddHash(hash)
+	}
+
+	difference := kmv1.Difference(kmv2)
+	card := difference.Cardinality()
+	relError := math.Abs(card-1000.0) / 1000.0
+	theoryError := 2 * difference.RelativeError()
+	if relError > theoryError {
+		t.Errorf("Relative error too high: %f instead of %f (ie: %f instead of %f)", relError, theoryError, card, 1000.)
+		t.FailNow()
+	}
+}
+
+func TestKMinValuesIntersectionFewMatches(t *testing.T) {
+	kmv1 := NewKMinValues(100)
+	kmv2 := NewKMinValues(100)
+	for i := 0; i < 1000; i++ {
+		kmv1.AddHash(GetHash([]byte(fmt.Sprintf("a%d", i))))
+		kmv2.AddHash(GetHash([]byte(fmt.Sprintf("b%d", i))))
+	}
+	// A hash smaller than any other is in both sets' k smallest
+	kmv1.AddHash(1)
+	kmv2.AddHash(1)
+
+	intersection := kmv1.Intersection(kmv2)
+	assert.Equal(t, intersection.Len(), 1)
+	assert.Equal(t, intersection.MaxSize(), 100)
+	assert.Equal(t, intersection.Exact(), false)
+	union := kmv1.Union(kmv2)
+	assert.Equal(t, intersection.Cardinality(), float64(1)/100*union.Cardinality())
+
+	difference := kmv1.Difference(kmv2)
+	assert.Equal(t, difference.Cardinality(), float64(difference.Len())/100*union.Cardinality())
+}
+
+func TestKMinValuesSmallK(t *testing.T) {
+	for _, k := range []int{1, 2} {
+		kmv := NewKMinValues(k)
+		kmv.AddHash(1 << 60)
+		if k == 2 {
+			assert.Equal(t, kmv.RelativeError(), 0.0)
+		}
+		for i := 0; i < 100; i++ {
+			kmv.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))))
+		}
+		relError := kmv.RelativeError()
+		assert.Equal(t, relError, 1.0)
+		lower, upper := kmv.Bounds(2)
+		if math.IsNaN(lower) || math.IsInf(upper, 0) || math.IsNaN(upper) {
+			t.Errorf("Bounds for k=%d aren't finite: %f, %f", k, lower, upper)
+		}
+	}
+}
+
+func TestKMinValuesCopy(t *testing.T) {
+	kmv := NewKMinValues(10)
+	kmv.AddHash(1)
+
+	kmv2 := kmv.Copy()
+	kmv2.AddHash(2)
+	assert.Equal(t, kmv.Len(), 1)
+	assert.Equal(t, kmv2.Len(), 2)
+	assert.Equal(t, kmv2.maxSize, 10)
+}
+
+func TestKMinValuesExact(t *testing.T) {
+	small := NewKMinValues(10)
+	large := NewKMinValues(1000)
+	for i := uint64(0); i < 20; i++ {
+		if i < 5 {
+			small.AddHash(i)
+		}
+		large.AddHash(i)
+	}
+	assert.Equal(t, small.Exact(), true)
+	assert.Equal(t, large.Exact(), true)
+
+	// an exact set shouldn't limit the size of the union
+	union := Union(small, large)
+	assert.Equal(t, union.Exact(), true)
+	assert.Equal(t, union.Cardinality(), 20.0)
+	assert.Equal(t, union.MaxSize(), 1000)
+	assert.Equal(t, small.CardinalityIntersection(large), 5.0)
+
+	for i := uint64(0); i < 20; i++ {
+		small.AddHash(i)
+	}
+	assert.Equal(t, small.Exact(), false)
+	assert.Equal(t, Union(small, large).MaxSize(), 10)
+}
+
+func TestKMinValuesContainment(t *testing.T) {
+	kmv1 := NewKMinValues(1024)
+	kmv2 := NewKMinValues(1024)
+
+	for i := 0; i < 4000; i++ {
+		hash := GetHash([]byte(fmt.Sprintf("%d", i)))
+		kmv1.AddHash(hash)
+	}
+	for i := 1000; i < 5000; i++ {
+		hash := GetHash([]byte(fmt.Sprintf("%d", i)))
+		kmv2.AddHash(hash)
+	}
+
+	// 3000 of kmv1's 4000 items are in kmv2 but only 3000 of kmv2's 4000
+	// are in kmv1, which is why containment isn't symmetric
+	containment := kmv1.Containment(kmv2)
+	theoryError := 2 * kmv1.RelativeError()
+	if math.Abs(containment-0.75) > theoryError {
+		t.Errorf("Containment too far off: %f instead of %f", containment, 0.75)
+		t.FailNow()
+	}
+
+	small := NewKMinValues(1024)
+	for _, hash := range []uint64{1, 2, 3, 4} {
+		small.AddHash(hash)
+	}
+	other := NewKMinValues(1024)
+	for _, hash := range []uint64{3, 4, 5, 6, 7, 8} {
+		other.AddHash(hash)
+	}
+	assert.Equal(t, small.Containment(other), 0.5)
+	assert.Equal(t, other.Containment(small), 2.0/6.0)
+}
+
+// When B is far larger than A only the few of A's hashes that are below B's
+// threshold can be checked, so the interval has to grow to still hold the
+// true containment
+func TestKMinValuesContainmentAccuracy(t *testing.T) {
+	a := NewKMinValues(1024)
+	b := NewKMinValues(1024)
+	for i := 0; i < 2000; i++ {
+		a.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))))
+	}
+	for i := 1000; i < 100000; i++ {
+		b.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))))
+	}
+
+	containment, lower, upper := ThetaContainmentBounds(StdDevs(0.99), a.Theta(), b.Theta())
+	if 0.5 < lower || 0.5 > upper {
+		t.Errorf("Containment bounds [%f, %f] don't contain %f (estimate %f)", lower, upper, 0.5, containment)
+	}
+
+	similar := NewKMinValues(1024)
+	for i := 1000; i < 3000; i++ {
+		similar.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))))
+	}
+	_, similarLower, similarUpper := ThetaContainmentBounds(StdDevs(0.99), a.Theta(), similar.Theta())
+	assert.T(t, upper-lower > similarUpper-similarLower)
+}
@@ -0,0 +1,165 @@
+package kminvalues
+
+import (
+	"errors"
+	"math"
+)
+
+// The kind of sketch a set is stored as.  A stored sketch starts with its
+// type byte.  KMinValues start with their size as a big endian uint64, whose
+// first byte is always 0, so TypeKMV is 0 and sets stored before there were
+// sketch types are read as KMinValues.
+type SketchType uint8
+
+const (
+	TypeKMV   SketchType = 0
+	TypeHLL   SketchType = 1
+	TypeTheta SketchType = 2
+)
+
+var (
+	InvalidSketchType     = errors.New("Invalid sketch type")
+	MixedSketchTypes      = errors.New("Sets of different sketch types can't be combined")
+	UnsupportedSketchType = errors.New("Operation not supported by the set's sketch type")
+)
+
+var sketchTypeNames = map[string]SketchType{
+	"kmv":   TypeKMV,
+	"hll":   TypeHLL,
+	"theta": TypeTheta,
+}
+
+// Returns the SketchType with the given name ("kmv", "hll" or "theta")
+func ParseSketchType(name string) (SketchType, error) {
+	sketchType, ok := sketchTypeNames[name]
+	if !ok {
+		return 0, InvalidSketchType
+	}
+	return sketchType, nil
+}
+
+func (t SketchType) String() string {
+	for name, sketchType := range sketchTypeNames {
+		if sketchType == t {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// What every kind of set can do.  Operations that need the hashes themselves,
+// such as intersections and jaccard indexes, are only available on
+// KMinValues and theta sketches.
+type Sketch interface {
+	Type() SketchType
+	AddHash(hash uint64) bool
+	Cardinality() float64
+	RelativeError() float64
+	Bounds(numStdDev float64) (float64, float64)
+	Exact() bool
+	MaxSize() int
+	Bytes() []byte
+}
+
+// Returns how many standard deviations either side of an estimate are needed
+// for an interval with the given confidence, eg: 1.96 for 0.95
+func StdDevs(confidence float64) float64 {
+	return math.Sqrt2 * math.Erfinv(confidence)
+}
+
+// Creates an empty sketch of the given type.  For HyperLogLogs size is the
+// smallest number of registers to use.
+func NewSketch(sketchType SketchType, size int) (Sketch, error) {
+	switch sketchType {
+	case TypeKMV:
+		return NewKMinValues(size), nil
+	case TypeHLL:
+		return NewHyperLogLog(size), nil
+	case TypeTheta:
+		return NewThetaSketch(size), nil
+	}
+	return nil, InvalidSketchType
+}
+
+// Reads a sketch of any type written with Bytes
+func SketchFromBytes(raw []byte) (Sketch, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("error reading data")
+	}
+	switch SketchType(raw[0]) {
+	case TypeKMV:
+		return KMinValuesFromBytes(raw)
+	case TypeHLL:
+		return HyperLogLogFromBytes(raw)
+	case TypeTheta:
+		return ThetaSketchFromBytes(raw)
+	}
+	return nil, InvalidSketchType
+}
+
+// Returns a copy of the sketch that doesn't share any memory with the
+// original
+func CopySketch(sketch Sketch) Sketch {
+	switch s := sketch.(type) {
+	case *KMinValues:
+		return s.Copy()
+	case *HyperLogLog:
+		return s.Copy()
+	case *ThetaSketch:
+		return s.Copy()
+	}
+	panic("unknown sketch type")
+}
+
+// Returns the union of sketches which all have to be of the same type.
+// KMinValues can be combined with theta sketches, giving a theta sketch, and
+// empty KMinValues, which is what sets that don't exist are read as, can be
+// combined with HyperLogLogs too.
+func UnionSketches(sketches ...Sketch) (Sketch, error) {
+	if len(sketches) == 0 {
+		return nil, errors.New("no sketches given")
+	}
+
+	hlls := make([]*HyperLogLog, 0, len(sketches))
+	empty, thetas := 0, 0
+	for _, sketch := range sketches {
+		switch s := sketch.(type) {
+		case *HyperLogLog:
+			hlls = append(hlls, s)
+		case *ThetaSketch:
+			thetas++
+		case *KMinValues:
+			if s.Len() == 0 {
+				empty++
+			}
+		default:
+			return nil, InvalidSketchType
+		}
+	}
+
+	if len(hlls) == 0 && thetas == 0 {
+		kmvs, _ := AsKMinValues(sketches...)
+		return Union(kmvs...), nil
+	} else if len(hlls) == 0 {
+		tss, _ := AsThetaSketches(sketches...)
+		return ThetaUnion(tss...), nil
+	}
+	if len(hlls)+empty != len(sketches) {
+		return nil, MixedSketchTypes
+	}
+	return hlls[0].Union(hlls[1:]...), nil
+}
+
+// Returns the sketches as KMinValues or UnsupportedSketchType if any of them
+// are of another type
+func AsKMinValues(sketches ...Sketch) ([]*KMinValues, error) {
+	kmvs := make([]*KMinValues, len(sketches))
+	for i, sketch := range sketches {
+		kmv, ok := sketch.(*KMinValues)
+		if !ok {
+			return nil, UnsupportedSketchType
+		}
+		kmvs[i] = kmv
+	}
+	return kmvs, nil
+}
@@ -0,0 +1,207 @@
+package kminvalues
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+const bytesSlidingEntry = 2 * bytesUint64
+
+type slidingEntry struct {
+	hash uint64
+	seen int64
+}
+
+// A KMinValues that remembers when every hash was last seen so that it can
+// answer questions about only the values seen since some time.  A hash is
+// kept for as long as it is one of the k smallest hashes seen since its last
+// sighting, which is exactly what is needed to build the KMinValues of any
+// window.  Hashes older than window (when it isn't 0) are thrown away
+// completely.  Times are unix timestamps in whatever unit the caller likes.
+type SlidingKMinValues struct {
+	entries   []slidingEntry
+	index     map[uint64]int
+	maxSize   int
+	window    int64
+	newest    int64
+	pruneSize int
+}
+
+func NewSlidingKMinValues(capacity int, window int64) *SlidingKMinValues {
+	return &SlidingKMinValues{
+		entries:   make([]slidingEntry, 0, capacity),
+		index:     make(map[uint64]int, capacity),
+		maxSize:   capacity,
+		window:    window,
+		pruneSize: 2 * capacity,
+	}
+}
+
+func SlidingKMinValuesFromBytes(raw []byte) (*SlidingKMinValues, error) {
+	if len(raw) < 3*bytesUint64 || (len(raw)-3*bytesUint64)%bytesSlidingEntry != 0 {
+		return nil, errors.New("error reading data")
+	}
+	maxSize := int(binary.BigEndian.Uint64(raw[0:]))
+	window := int64(binary.BigEndian.Uint64(raw[bytesUint64:]))
+	newest := int64(binary.BigEndian.Uint64(raw[2*bytesUint64:]))
+
+	skmv := NewSlidingKMinValues(maxSize, window)
+	skmv.newest = newest
+	for i := 3 * bytesUint64; i < len(raw); i += bytesSlidingEntry {
+		skmv.index[binary.BigEndian.Uint64(raw[i:])] = len(skmv.entries)
+		skmv.entries = append(skmv.entries, slidingEntry{
+			hash: binary.BigEndian.Uint64(raw[i:]),
+			seen: int64(binary.BigEndian.Uint64(raw[i+bytesUint64:])),
+		})
+	}
+	return skmv, nil
+}
+
+func (skmv *SlidingKMinValues) Bytes() []byte {
+	skmv.prune()
+	buffer := bytes.NewBuffer(make([]byte, 0, 3*bytesUint64+len(skmv.entries)*bytesSlidingEntry))
+	binary.Write(buffer, binary.BigEndian, uint64(skmv.maxSize))
+	binary.Write(buffer, binary.BigEndian, skmv.window)
+	binary.Write(buffer, binary.BigEndian, skmv.newest)
+	for _, entry := range skmv.entries {
+		binary.Write(buffer, binary.BigEndian, entry.hash)
+		binary.Write(buffer, binary.BigEndian, entry.seen)
+	}
+	return buffer.Bytes()
+}
+
+func (skmv *SlidingKMinValues) Len() int { return len(skmv.entries) }
+
+func (skmv *SlidingKMinValues) MaxSize() int { return skmv.maxSize }
+
+// Records that hash was seen at the given time
+func (skmv *SlidingKMinValues) AddHash(hash uint64, seen int64) {
+	if seen > skmv.newest {
+		skmv.newest = seen
+	}
+	if i, found := skmv.index[hash]; found {
+		if skmv.entries[i].seen < seen {
+			skmv.entries[i].seen = seen
+		}
+		return
+	}
+
+	skmv.index[hash] = len(skmv.entries)
+	skmv.entries = append(skmv.entries, slidingEntry{hash, seen})
+	if len(skmv.entries) >= skmv.pruneSize {
+		skmv.prune()
+	}
+}
+
+// Throws away every hash that can't be in the KMinValues of any window.  We
+// walk through the hashes from the most to the least recently seen while
+// keeping the k smallest hashes we've come across.  A hash is only needed if
+// it is one of those k smallest when we reach it.
+func (skmv *SlidingKMinValues) prune() {
+	sort.Sort(bySeen(skmv.entries))
+
+	smallest := make(maxHeap, 0, skmv.maxSize)
+	kept := skmv.entries[:0]
+	for _, entry := range skmv.entries {
+		if skmv.window > 0 && entry.seen < skmv.newest-skmv.window {
+			break
+		}
+		if len(smallest) < skmv.maxSize {
+			smallest.push(entry.hash)
+		} else if entry.hash < smallest[0] {
+			smallest.replaceTop(entry.hash)
+		} else {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+
+	skmv.entries = kept
+	skmv.index = make(map[uint64]int, len(kept))
+	for i, entry := range kept {
+		skmv.index[entry.hash] = i
+	}
+	skmv.pruneSize = 2 * len(kept)
+	if skmv.pruneSize < 2*skmv.maxSize {
+		skmv.pruneSize = 2 * skmv.maxSize
+	}
+}
+
+// Returns the KMinValues of every hash seen at or after the given time
+func (skmv *SlidingKMinValues) Since(since int64) *KMinValues {
+	hashes := make([]uint64, 0, len(skmv.entries))
+	for _, entry := range skmv.entries {
+		if entry.seen >= since && (skmv.window == 0 || entry.seen >= skmv.newest-skmv.window) {
+			hashes = append(hashes, entry.hash)
+		}
+	}
+	sort.Sort(sort.Reverse(uint64Slice(hashes)))
+	if len(hashes) > skmv.maxSize {
+		hashes = hashes[len(hashes)-skmv.maxSize:]
+	}
+
+	kmv := &KMinValues{
+		raw:     make([]byte, len(hashes)*bytesUint64),
+		maxSize: skmv.maxSize,
+	}
+	for i, hash := range hashes {
+		binary.BigEndian.PutUint64(kmv.raw[i*bytesUint64:], hash)
+	}
+	return kmv
+}
+
+func (skmv *SlidingKMinValues) CardinalitySince(since int64) float64 {
+	return skmv.Since(since).Cardinality()
+}
+
+type bySeen []slidingEntry
+
+func (s bySeen) Len() int      { return len(s) }
+func (s bySeen) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s bySeen) Less(i, j int) bool {
+	if s[i].seen == s[j].seen {
+		return s[i].hash < s[j].hash
+	}
+	return s[i].seen > s[j].seen
+}
+
+type uint64Slice []uint64
+
+func (s uint64Slice) Len() int           { return len(s) }
+func (s uint64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s uint64Slice) Less(i, j int) bool { return s[i] < s[j] }
+
+// A fixed size max-heap of hashes
+type maxHeap []uint64
+
+func (h *maxHeap) push(hash uint64) {
+	*h = append(*h, hash)
+	heap := *h
+	for i := len(heap) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if heap[parent] >= heap[i] {
+			break
+		}
+		heap[parent], heap[i] = heap[i], heap[parent]
+		i = parent
+	}
+}
+
+func (h maxHeap) replaceTop(hash uint64) {
+	h[0] = hash
+	for i := 0; ; {
+		largest := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(h) && h[child] > h[largest] {
+				largest = child
+			}
+		}
+		if largest == i {
+			return
+		}
+		h[i], h[largest] = h[largest], h[i]
+		i = largest
+	}
+}
@@ -0,0 +1,74 @@
+package kminvalues
+
+import (
+	"fmt"
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestSlidingKMinValuesSince(t *testing.T) {
+	skmv := NewSlidingKMinValues(100, 0)
+
+	// Values 0-9999 are each seen once at time i and values 0-999 are seen
+	// again at the end
+	for i := 0; i < 10000; i++ {
+		skmv.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))), int64(i))
+	}
+	for i := 0; i < 1000; i++ {
+		skmv.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))), int64(10000+i))
+	}
+
+	for _, since := range []int64{0, 5000, 9000, 9950, 10500} {
+		expected := NewKMinValues(100)
+		for i := int64(0); i < 10000; i++ {
+			if i >= since || i < 1000 && i+10000 >= since {
+				expected.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))))
+			}
+		}
+
+		kmv := skmv.Since(since)
+		assert.Equal(t, kmv.Len(), expected.Len())
+		for i := 0; i < kmv.Len(); i++ {
+			assert.Equal(t, kmv.GetHash(i), expected.GetHash(i))
+		}
+		assert.Equal(t, skmv.CardinalitySince(since), expected.Cardinality())
+	}
+
+	if skmv.Len() > 20*100 {
+		t.Errorf("Sliding KMV is keeping too many hashes: %d", skmv.Len())
+		t.FailNow()
+	}
+}
+
+func TestSlidingKMinValuesWindow(t *testing.T) {
+	skmv := NewSlidingKMinValues(100, 50)
+
+	for i := 0; i < 200; i++ {
+		skmv.AddHash(uint64(i), int64(i))
+	}
+	assert.Equal(t, skmv.CardinalitySince(0), 51.0)
+	assert.Equal(t, skmv.CardinalitySince(190), 10.0)
+
+	skmv.Bytes()
+	assert.Equal(t, skmv.Len(), 51)
+}
+
+func TestSlidingKMinValuesBytes(t *testing.T) {
+	skmv := NewSlidingKMinValues(50, 1000)
+	for i := 0; i < 500; i++ {
+		skmv.AddHash(GetRandHash(), int64(i))
+	}
+
+	skmv2, err := SlidingKMinValuesFromBytes(skmv.Bytes())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, skmv2.maxSize, skmv.maxSize)
+	assert.Equal(t, skmv2.window, skmv.window)
+	assert.Equal(t, skmv2.newest, skmv.newest)
+	assert.Equal(t, skmv2.entries, skmv.entries)
+
+	skmv2.AddHash(skmv.entries[0].hash, 1000)
+	assert.Equal(t, skmv2.Len(), skmv.Len())
+
+	_, err = SlidingKMinValuesFromBytes([]byte{1, 2, 3})
+	assert.NotEqual(t, err, nil)
+}
@@ -0,0 +1,373 @@
+package kminvalues
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const thetaMax = math.MaxUint64
+
+// A sketch of every hash smaller than theta.  Unlike a KMinValues the
+// threshold is kept explicitly, so the result of an intersection or a
+// difference is a sketch too (with fewer hashes under the same theta) and can
+// be used in further operations.  A theta of thetaMax means that no hash has
+// been thrown away and the sketch is exact.
+type ThetaSketch struct {
+	hashes []uint64
+	theta  uint64
+	k      int
+}
+
+// Creates a theta sketch that keeps the k smallest hashes added to it
+func NewThetaSketch(k int) *ThetaSketch {
+	return &ThetaSketch{
+		hashes: make([]uint64, 0, k),
+		theta:  thetaMax,
+		k:      k,
+	}
+}
+
+func ThetaSketchFromBytes(raw []byte) (*ThetaSketch, error) {
+	if len(raw) < 13 || SketchType(raw[0]) != TypeTheta || (len(raw)-13)%bytesUint64 != 0 {
+		return nil, errors.New("error reading data")
+	}
+	ts := &ThetaSketch{
+		hashes: make([]uint64, 0, (len(raw)-13)/bytesUint64),
+		k:      int(binary.BigEndian.Uint32(raw[1:])),
+		theta:  binary.BigEndian.Uint64(raw[5:]),
+	}
+	for i := 13; i < len(raw); i += bytesUint64 {
+		ts.hashes = append(ts.hashes, binary.BigEndian.Uint64(raw[i:]))
+	}
+	return ts, nil
+}
+
+func (ts *ThetaSketch) Bytes() []byte {
+	buffer := bytes.NewBuffer(make([]byte, 0, 13+len(ts.hashes)*bytesUint64))
+	buffer.WriteByte(byte(TypeTheta))
+	binary.Write(buffer, binary.BigEndian, uint32(ts.k))
+	binary.Write(buffer, binary.BigEndian, ts.theta)
+	binary.Write(buffer, binary.BigEndian, ts.hashes)
+	return buffer.Bytes()
+}
+
+func (ts *ThetaSketch) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, `{"type":"theta", "k":%d, "theta":%d, "exact":%t, "data":[`, ts.k, ts.theta, ts.Exact())
+	for i, hash := range ts.hashes {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		fmt.Fprintf(&buffer, "%d", hash)
+	}
+	buffer.WriteString("]}")
+	return buffer.Bytes(), nil
+}
+
+// Returns a copy of the sketch that doesn't share any memory with the
+// original
+func (ts *ThetaSketch) Copy() *ThetaSketch {
+	capacity := ts.k
+	if capacity < len(ts.hashes) {
+		capacity = len(ts.hashes)
+	}
+	hashes := make([]uint64, len(ts.hashes), capacity)
+	copy(hashes, ts.hashes)
+	return &ThetaSketch{
+		hashes: hashes,
+		theta:  ts.theta,
+		k:      ts.k,
+	}
+}
+
+func (ts *ThetaSketch) Type() SketchType { return TypeTheta }
+
+func (ts *ThetaSketch) MaxSize() int { return ts.k }
+
+// The number of hashes kept, which are all smaller than theta
+func (ts *ThetaSketch) Len() int { return len(ts.hashes) }
+
+// The fraction of the hash space the sketch covers
+func (ts *ThetaSketch) Theta() float64 {
+	if ts.theta == thetaMax {
+		return 1
+	}
+	return float64(ts.theta) / hashMax
+}
+
+// A sketch that never had to throw a hash away knows every item exactly
+func (ts *ThetaSketch) Exact() bool { return ts.theta == thetaMax }
+
+func (ts *ThetaSketch) contains(hash uint64) bool {
+	i := sort.Search(len(ts.hashes), func(i int) bool { return ts.hashes[i] >= hash })
+	return i < len(ts.hashes) && ts.hashes[i] == hash
+}
+
+func (ts *ThetaSketch) AddHash(hash uint64) bool {
+	if hash >= ts.theta {
+		return false
+	}
+	i := sort.Search(len(ts.hashes), func(i int) bool { return ts.hashes[i] >= hash })
+	if i < len(ts.hashes) && ts.hashes[i] == hash {
+		return false
+	}
+	ts.hashes = append(ts.hashes, 0)
+	copy(ts.hashes[i+1:], ts.hashes[i:])
+	ts.hashes[i] = hash
+	ts.trim()
+	return hash < ts.theta
+}
+
+// Drops hashes until there are at most k of them.  The smallest dropped hash
+// becomes the new theta.
+func (ts *ThetaSketch) trim() {
+	if ts.k > 0 && len(ts.hashes) > ts.k {
+		ts.theta = ts.hashes[ts.k]
+		ts.hashes = ts.hashes[:ts.k]
+	}
+}
+
+// Estimates the number of distinct hashes as the number of hashes kept over
+// the fraction of the hash space that they cover, which is unbiased
+func (ts *ThetaSketch) Cardinality() float64 {
+	return float64(len(ts.hashes)) / ts.Theta()
+}
+
+func (ts *ThetaSketch) RelativeError() float64 {
+	if ts.Exact() {
+		return 0
+	}
+	return math.Sqrt((1 - ts.Theta()) / math.Max(float64(len(ts.hashes)), 1))
+}
+
+// Returns an interval around Cardinality that is numStdDev standard
+// deviations wide on either side.  Every hash kept is a distinct item, so the
+// lower bound is never less than the number of hashes.
+func (ts *ThetaSketch) Bounds(numStdDev float64) (float64, float64) {
+	n := float64(len(ts.hashes))
+	if ts.Exact() {
+		return n, n
+	}
+	p := ts.Theta()
+	estimate := n / p
+	lower := estimate - numStdDev*math.Sqrt(n*(1-p))/p
+	upper := estimate + numStdDev*math.Sqrt(math.Max(n, 1)*(1-p))/p
+	return math.Max(lower, n), upper
+}
+
+// Returns the theta sketch of a KMinValues.  A full KMinValues holds the k
+// smallest hashes, so its largest hash is theta and the other k-1 are kept.
+// The subset of a full union has the union's largest hash as theta.
+func (kmv *KMinValues) Theta() *ThetaSketch {
+	N := kmv.Len()
+	ts := NewThetaSketch(kmv.maxSize)
+	start := 0
+	if kmv.threshold != 0 {
+		ts.theta = kmv.threshold
+		if N > 0 && kmv.GetHash(0) == kmv.threshold {
+			start = 1
+		}
+	} else if N >= kmv.maxSize && N > 0 {
+		ts.theta = kmv.GetHash(0)
+		start = 1
+	}
+	for i := N - 1; i >= start; i-- {
+		ts.hashes = append(ts.hashes, kmv.GetHash(i))
+	}
+	return ts
+}
+
+// Returns the sketches as theta sketches.  KMinValues are converted and any
+// other type gives UnsupportedSketchType.
+func AsThetaSketches(sketches ...Sketch) ([]*ThetaSketch, error) {
+	thetas := make([]*ThetaSketch, len(sketches))
+	for i, sketch := range sketches {
+		switch s := sketch.(type) {
+		case *ThetaSketch:
+			thetas[i] = s
+		case *KMinValues:
+			thetas[i] = s.Theta()
+		default:
+			return nil, UnsupportedSketchType
+		}
+	}
+	return thetas, nil
+}
+
+// The smallest theta of all the sketches and the k to use for a sketch made
+// out of them, which like a KMinValues union ignores exact sketches unless
+// they all are
+func minTheta(sketches []*ThetaSketch) (uint64, int) {
+	theta, k, exactK := uint64(thetaMax), 0, 0
+	for _, ts := range sketches {
+		if ts.theta < theta {
+			theta = ts.theta
+		}
+		if ts.Exact() {
+			if ts.k > exactK {
+				exactK = ts.k
+			}
+		} else if k == 0 || ts.k < k {
+			k = ts.k
+		}
+	}
+	if k == 0 {
+		k = exactK
+	}
+	return theta, k
+}
+
+// Creates a sketch under the smallest theta of all of the sketches out of
+// the hashes of the first sketch that pass the keep filter
+func filterTheta(sketches []*ThetaSketch, keep func(uint64) bool) *ThetaSketch {
+	theta, k := minTheta(sketches)
+	result := &ThetaSketch{
+		hashes: make([]uint64, 0),
+		theta:  theta,
+		k:      k,
+	}
+	for _, hash := range sketches[0].hashes {
+		if hash >= theta {
+			break
+		}
+		if keep(hash) {
+			result.hashes = append(result.hashes, hash)
+		}
+	}
+	return result
+}
+
+// Returns the union of the sketches under the smallest of their thetas,
+// trimmed to the smallest k
+func ThetaUnion(sketches ...*ThetaSketch) *ThetaSketch {
+	theta, k := minTheta(sketches)
+	seen := make(map[uint64]bool)
+	hashes := make([]uint64, 0)
+	for _, ts := range sketches {
+		for _, hash := range ts.hashes {
+			if hash >= theta {
+				break
+			}
+			if !seen[hash] {
+				seen[hash] = true
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	sort.Sort(uint64Slice(hashes))
+
+	union := &ThetaSketch{
+		hashes: hashes,
+		theta:  theta,
+		k:      k,
+	}
+	union.trim()
+	return union
+}
+
+// Returns the intersection of the sketches.  Every sketch holds all of its
+// hashes below the smallest theta, so the hashes below it that are in every
+// sketch are exactly the intersection's.
+func ThetaIntersection(sketches ...*ThetaSketch) *ThetaSketch {
+	return filterTheta(sketches, func(hash uint64) bool {
+		for _, ts := range sketches[1:] {
+			if !ts.contains(hash) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Returns the sketch of the items in the first sketch that aren't in any of
+// the others
+func ThetaAnotB(sketches ...*ThetaSketch) *ThetaSketch {
+	return filterTheta(sketches, func(hash uint64) bool {
+		for _, ts := range sketches[1:] {
+			if ts.contains(hash) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Estimates the fraction of a's items that are also in b
+func ThetaContainment(a, b *ThetaSketch) float64 {
+	containment, _, _ := ThetaContainmentBounds(0, a, b)
+	return containment
+}
+
+// Returns the fraction of a's items that are also in b along with an interval
+// that is numStdDev standard deviations wide on either side.  Only a's hashes
+// below both thetas can be checked against b, so when b is much larger than a
+// (and has a much smaller theta) few of a's hashes are left and the interval
+// gets wide.
+func ThetaContainmentBounds(numStdDev float64, a, b *ThetaSketch) (float64, float64, float64) {
+	theta := a.theta
+	if b.theta < theta {
+		theta = b.theta
+	}
+	n, shared := 0, 0
+	for _, hash := range a.hashes {
+		if hash >= theta {
+			break
+		}
+		n++
+		if b.contains(hash) {
+			shared++
+		}
+	}
+	if n == 0 {
+		return 0, 0, 0
+	}
+
+	containment := float64(shared) / float64(n)
+	if theta == thetaMax {
+		return containment, containment, containment
+	}
+	delta := numStdDev * math.Sqrt(containment*(1-containment)/float64(n))
+	return containment, math.Max(containment-delta, 0), math.Min(containment+delta, 1)
+}
+
+// Estimates the jaccard index of the sketches as the fraction of the hashes
+// in their union that are in all of them
+func ThetaJaccard(sketches ...*ThetaSketch) float64 {
+	jaccard, _, _ := ThetaJaccardBounds(0, sketches...)
+	return jaccard
+}
+
+// Returns the jaccard index of the sketches along with an interval that is
+// numStdDev standard deviations wide on either side.  The hashes of the union
+// are a sample of the union's items, so the fraction of them that are shared
+// is binomially distributed.
+func ThetaJaccardBounds(numStdDev float64, sketches ...*ThetaSketch) (float64, float64, float64) {
+	union := ThetaUnion(sketches...)
+	if union.Len() == 0 {
+		return 0, 0, 0
+	}
+	shared := 0
+	for _, hash := range union.hashes {
+		inAll := true
+		for _, ts := range sketches {
+			if !ts.contains(hash) {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			shared++
+		}
+	}
+	n := float64(union.Len())
+	jaccard := float64(shared) / n
+	if union.Exact() {
+		return jaccard, jaccard, jaccard
+	}
+	delta := numStdDev * math.Sqrt(jaccard*(1-jaccard)/n)
+	return jaccard, math.Max(jaccard-delta, 0), math.Min(jaccard+delta, 1)
+}
@@ -0,0 +1,120 @@
+package kminvalues
+
+import (
+	"fmt"
+	"github.com/bmizerany/assert"
+	"math"
+	"testing"
+)
+
+func thetaRange(k, from, to int) *ThetaSketch {
+	ts := NewThetaSketch(k)
+	for i := from; i < to; i++ {
+		ts.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))))
+	}
+	return ts
+}
+
+func checkEstimate(t *testing.T, name string, ts *ThetaSketch, truth float64) {
+	lower, upper := ts.Bounds(3)
+	if truth < lower || truth > upper {
+		t.Errorf("%s: %f not within [%f, %f] (estimate %f)", name, truth, lower, upper, ts.Cardinality())
+		t.FailNow()
+	}
+}
+
+func TestThetaSketchSimple(t *testing.T) {
+	ts := NewThetaSketch(3)
+	for _, hash := range []uint64{5, 1, 3, 1} {
+		ts.AddHash(hash)
+	}
+	assert.Equal(t, ts.Exact(), true)
+	assert.Equal(t, ts.Cardinality(), 3.0)
+
+	assert.Equal(t, ts.AddHash(2), true)
+	assert.Equal(t, ts.theta, uint64(5))
+	assert.Equal(t, ts.hashes, []uint64{1, 2, 3})
+	assert.Equal(t, ts.AddHash(7), false)
+
+	ts2, err := ThetaSketchFromBytes(ts.Bytes())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ts2, ts)
+}
+
+func TestThetaSketchFromKMinValues(t *testing.T) {
+	kmv := NewKMinValues(1000)
+	for i := 0; i < 5000; i++ {
+		kmv.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))))
+	}
+	ts := kmv.Theta()
+	assert.Equal(t, ts.Len(), 999)
+	if math.Abs(ts.Cardinality()-kmv.Cardinality()) > 1e-6 {
+		t.Errorf("Theta estimate %f doesn't match KMinValues estimate %f", ts.Cardinality(), kmv.Cardinality())
+	}
+
+	small := NewKMinValues(10)
+	small.AddHash(1)
+	small.AddHash(2)
+	assert.Equal(t, small.Theta().Exact(), true)
+	assert.Equal(t, small.Theta().hashes, []uint64{1, 2})
+}
+
+func TestThetaSketchOperations(t *testing.T) {
+	a := thetaRange(1024, 0, 20000)
+	b := thetaRange(1024, 15000, 40000)
+	c := thetaRange(1024, 18000, 19000)
+
+	checkEstimate(t, "a", a, 20000)
+	checkEstimate(t, "a u b", ThetaUnion(a, b), 40000)
+	checkEstimate(t, "a n b", ThetaIntersection(a, b), 5000)
+	checkEstimate(t, "a \\ b", ThetaAnotB(a, b), 15000)
+	checkEstimate(t, "(a n b) n c", ThetaIntersection(ThetaIntersection(a, b), c), 1000)
+	checkEstimate(t, "(a u b) \\ (a n b)", ThetaAnotB(ThetaUnion(a, b), ThetaIntersection(a, b)), 35000)
+
+	jaccard := ThetaJaccard(a, b)
+	if math.Abs(jaccard-0.125) > 0.05 {
+		t.Errorf("Jaccard too far off: %f instead of %f", jaccard, 0.125)
+	}
+}
+
+func TestThetaSketchSmall(t *testing.T) {
+	a := thetaRange(100, 0, 10)
+	b := thetaRange(100, 5, 20)
+	assert.Equal(t, ThetaIntersection(a, b).Cardinality(), 5.0)
+	assert.Equal(t, ThetaAnotB(a, b).Cardinality(), 5.0)
+	assert.Equal(t, ThetaUnion(a, b).Cardinality(), 20.0)
+	assert.Equal(t, ThetaJaccard(a, b), 0.25)
+
+	lower, upper := ThetaIntersection(a, b).Bounds(3)
+	assert.Equal(t, lower, 5.0)
+	assert.Equal(t, upper, 5.0)
+}
+
+func TestThetaJaccardBounds(t *testing.T) {
+	a := thetaRange(1024, 0, 20000)
+	b := thetaRange(1024, 15000, 40000)
+
+	z := StdDevs(0.99)
+	if math.Abs(z-2.5758) > 1e-3 {
+		t.Errorf("Wrong number of standard deviations for 0.99: %f", z)
+	}
+	jaccard, lower, upper := ThetaJaccardBounds(z, a, b)
+	assert.Equal(t, jaccard, ThetaJaccard(a, b))
+	if 0.125 < lower || 0.125 > upper {
+		t.Errorf("Jaccard bounds [%f, %f] don't contain %f", lower, upper, 0.125)
+	}
+
+	kmv := NewKMinValues(1024)
+	hll := NewHyperLogLog(1024)
+	for i := 0; i < 20000; i++ {
+		hash := GetHash([]byte(fmt.Sprintf("%d", i)))
+		kmv.AddHash(hash)
+		hll.AddHash(hash)
+	}
+	for _, sketch := range []Sketch{kmv, hll} {
+		lower, upper := sketch.Bounds(z)
+		if 20000 < lower || 20000 > upper {
+			t.Errorf("Bounds of %s [%f, %f] don't contain 20000", sketch.Type(), lower, upper)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package kminvalues
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+const bytesWeightedEntry = 2 * bytesUint64
+
+// How the values of a hash that is added more than once get combined
+type Merge uint8
+
+const (
+	MergeSum Merge = iota
+	MergeMax
+	MergeLast
+)
+
+var InvalidMerge = errors.New("Invalid merge")
+
+var mergeNames = map[string]Merge{
+	"sum":  MergeSum,
+	"max":  MergeMax,
+	"last": MergeLast,
+}
+
+// Returns the Merge with the given name ("sum", "max" or "last")
+func ParseMerge(name string) (Merge, error) {
+	merge, ok := mergeNames[name]
+	if !ok {
+		return 0, InvalidMerge
+	}
+	return merge, nil
+}
+
+func (m Merge) combine(old, value float64) float64 {
+	switch m {
+	case MergeMax:
+		return math.Max(old, value)
+	case MergeLast:
+		return value
+	}
+	return old + value
+}
+
+type weightedEntry struct {
+	hash  uint64
+	value float64
+}
+
+// A KMinValues that keeps a value next to every hash.  Since the hashes kept
+// are a uniform sample of the distinct items their values are a uniform
+// sample of the per item values, which gives estimates for the sum and mean
+// of the values over all distinct items.  Like KMinValues the entries are
+// kept with the largest hash first.
+type WeightedKMinValues struct {
+	entries []weightedEntry
+	maxSize int
+	merge   Merge
+}
+
+func NewWeightedKMinValues(capacity int, merge Merge) *WeightedKMinValues {
+	return &WeightedKMinValues{
+		entries: make([]weightedEntry, 0, capacity),
+		maxSize: capacity,
+		merge:   merge,
+	}
+}
+
+func WeightedKMinValuesFromBytes(raw []byte) (*WeightedKMinValues, error) {
+	if len(raw) < 2*bytesUint64 || (len(raw)-2*bytesUint64)%bytesWeightedEntry != 0 {
+		return nil, errors.New("error reading data")
+	}
+	maxSize := int(binary.BigEndian.Uint64(raw[0:]))
+	merge := Merge(binary.BigEndian.Uint64(raw[bytesUint64:]))
+
+	wkmv := NewWeightedKMinValues(maxSize, merge)
+	for i := 2 * bytesUint64; i < len(raw); i += bytesWeightedEntry {
+		wkmv.entries = append(wkmv.entries, weightedEntry{
+			hash:  binary.BigEndian.Uint64(raw[i:]),
+			value: math.Float64frombits(binary.BigEndian.Uint64(raw[i+bytesUint64:])),
+		})
+	}
+	return wkmv, nil
+}
+
+func (wkmv *WeightedKMinValues) Bytes() []byte {
+	buffer := bytes.NewBuffer(make([]byte, 0, 2*bytesUint64+len(wkmv.entries)*bytesWeightedEntry))
+	binary.Write(buffer, binary.BigEndian, uint64(wkmv.maxSize))
+	binary.Write(buffer, binary.BigEndian, uint64(wkmv.merge))
+	for _, entry := range wkmv.entries {
+		binary.Write(buffer, binary.BigEndian, entry.hash)
+		binary.Write(buffer, binary.BigEndian, math.Float64bits(entry.value))
+	}
+	return buffer.Bytes()
+}
+
+func (wkmv *WeightedKMinValues) Len() int { return len(wkmv.entries) }
+
+func (wkmv *WeightedKMinValues) MaxSize() int { return wkmv.maxSize }
+
+func (wkmv *WeightedKMinValues) Merge() Merge { return wkmv.merge }
+
+// Whether the set still holds every item added to it
+func (wkmv *WeightedKMinValues) Exact() bool { return len(wkmv.entries) < wkmv.maxSize }
+
+// Returns the value stored for hash and whether the hash is in the set
+func (wkmv *WeightedKMinValues) GetValue(hash uint64) (float64, bool) {
+	i, found := wkmv.locate(hash)
+	if !found {
+		return 0, false
+	}
+	return wkmv.entries[i].value, true
+}
+
+func (wkmv *WeightedKMinValues) locate(hash uint64) (int, bool) {
+	i := sort.Search(len(wkmv.entries), func(i int) bool {
+		return wkmv.entries[i].hash <= hash
+	})
+	return i, i < len(wkmv.entries) && wkmv.entries[i].hash == hash
+}
+
+// Adds value to the item with the given hash.  If the hash is already in the
+// set the values are combined with the set's Merge.  Returns whether the hash
+// is in the set afterwards.
+func (wkmv *WeightedKMinValues) AddHash(hash uint64, value float64) bool {
+	i, found := wkmv.locate(hash)
+	if found {
+		wkmv.entries[i].value = wkmv.merge.combine(wkmv.entries[i].value, value)
+		return true
+	}
+	if len(wkmv.entries) >= wkmv.maxSize {
+		if i == 0 {
+			return false
+		}
+		copy(wkmv.entries[:i-1], wkmv.entries[1:i])
+		wkmv.entries[i-1] = weightedEntry{hash, value}
+		return true
+	}
+	wkmv.entries = append(wkmv.entries, weightedEntry{})
+	copy(wkmv.entries[i+1:], wkmv.entries[i:])
+	wkmv.entries[i] = weightedEntry{hash, value}
+	return true
+}
+
+// Returns the plain KMinValues of the hashes in the set so it can be used
+// with all the usual set operations
+func (wkmv *WeightedKMinValues) KMinValues() *KMinValues {
+	kmv := NewKMinValues(wkmv.maxSize)
+	for _, entry := range wkmv.entries {
+		kmv.raw = append(kmv.raw, hashUint64ToBytes(entry.hash)...)
+	}
+	return kmv
+}
+
+func (wkmv *WeightedKMinValues) Cardinality() float64 {
+	if wkmv.Exact() {
+		return float64(len(wkmv.entries))
+	}
+	return cardinality(wkmv.maxSize, wkmv.entries[0].hash)
+}
+
+// Estimates the mean of the values over all distinct items
+func (wkmv *WeightedKMinValues) Mean() float64 {
+	if len(wkmv.entries) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, entry := range wkmv.entries {
+		total += entry.value
+	}
+	return total / float64(len(wkmv.entries))
+}
+
+// Estimates the sum of the values over all distinct items.  This is exact
+// while the set hasn't filled up.
+func (wkmv *WeightedKMinValues) Sum() float64 {
+	return wkmv.Mean() * wkmv.Cardinality()
+}
+
+// Returns the mean with an interval that is numStdDev standard errors wide
+// on either side
+func (wkmv *WeightedKMinValues) MeanBounds(numStdDev float64) (float64, float64, float64) {
+	mean := wkmv.Mean()
+	n := float64(len(wkmv.entries))
+	if n < 2 || wkmv.Exact() {
+		return mean, mean, mean
+	}
+	variance := 0.0
+	for _, entry := range wkmv.entries {
+		variance += (entry.value - mean) * (entry.value - mean)
+	}
+	delta := numStdDev * math.Sqrt(variance/(n-1)/n)
+	return mean, mean - delta, mean + delta
+}
+
+// Returns the sum with an interval that is numStdDev standard errors wide on
+// either side.  The errors of the mean and the cardinality are combined as if
+// they were independent.
+func (wkmv *WeightedKMinValues) SumBounds(numStdDev float64) (float64, float64, float64) {
+	sum := wkmv.Sum()
+	if wkmv.Exact() {
+		return sum, sum, sum
+	}
+	mean, lower, _ := wkmv.MeanBounds(numStdDev)
+	card := wkmv.Cardinality()
+	meanDelta := card * (mean - lower)
+	cardDelta := mean * numStdDev * wkmv.RelativeError() * card
+	delta := math.Sqrt(meanDelta*meanDelta + cardDelta*cardDelta)
+	return sum, sum - delta, sum + delta
+}
+
+// The standard error of Cardinality relative to it, which like a KMinValues'
+// is as large as the estimate itself for a full set with k under 3
+func (wkmv *WeightedKMinValues) RelativeError() float64 {
+	if wkmv.maxSize < 3 {
+		if wkmv.Exact() {
+			return 0
+		}
+		return 1
+	}
+	return math.Sqrt(2.0 / (math.Pi * float64(wkmv.maxSize-2)))
+}
+
+// Combines the sets into one covering all of their items.  Values for hashes
+// in more than one set are combined with the first set's Merge and the result
+// is as large as the smallest set.
+func (wkmv *WeightedKMinValues) Union(others ...*WeightedKMinValues) *WeightedKMinValues {
+	maxSize := wkmv.maxSize
+	for _, other := range others {
+		if other.maxSize < maxSize {
+			maxSize = other.maxSize
+		}
+	}
+
+	union := NewWeightedKMinValues(maxSize, wkmv.merge)
+	for _, set := range append([]*WeightedKMinValues{wkmv}, others...) {
+		for _, entry := range set.entries {
+			union.AddHash(entry.hash, entry.value)
+		}
+	}
+	return union
+}
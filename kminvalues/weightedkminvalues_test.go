@@ -0,0 +1,124 @@
+package kminvalues
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bmizerany/assert"
+	"math"
+	"testing"
+)
+
+func TestWeightedKMinValuesSimple(t *testing.T) {
+	wkmv := NewWeightedKMinValues(3, MergeSum)
+	wkmv.AddHash(5, 1)
+	wkmv.AddHash(2, 1)
+	wkmv.AddHash(5, 2)
+	assert.Equal(t, wkmv.Len(), 2)
+	assert.Equal(t, wkmv.Sum(), 4.0)
+	wkmv.AddHash(9, 4)
+	value, _ := wkmv.GetValue(5)
+	assert.Equal(t, value, 3.0)
+
+	wkmv.AddHash(1, 10)
+	_, found := wkmv.GetValue(9)
+	assert.Equal(t, found, false)
+	assert.Equal(t, wkmv.AddHash(20, 1), false)
+	for i, hash := range []uint64{5, 2, 1} {
+		assert.Equal(t, wkmv.entries[i].hash, hash)
+	}
+
+	wkmv = NewWeightedKMinValues(3, MergeMax)
+	wkmv.AddHash(5, 3)
+	wkmv.AddHash(5, 1)
+	value, _ = wkmv.GetValue(5)
+	assert.Equal(t, value, 3.0)
+
+	wkmv = NewWeightedKMinValues(3, MergeLast)
+	wkmv.AddHash(5, 3)
+	wkmv.AddHash(5, 1)
+	value, _ = wkmv.GetValue(5)
+	assert.Equal(t, value, 1.0)
+
+	_, err := ParseMerge("avg")
+	assert.Equal(t, err, InvalidMerge)
+}
+
+func TestWeightedKMinValuesSum(t *testing.T) {
+	wkmv := NewWeightedKMinValues(1000, MergeSum)
+	total := 0.0
+	for i := 0; i < 10000; i++ {
+		value := float64(i % 100)
+		total += value
+		wkmv.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))), value)
+	}
+
+	relError := math.Abs(wkmv.Sum()-total) / total
+	theoryError := 2 * wkmv.RelativeError()
+	if relError > theoryError {
+		t.Errorf("Relative error too high: %f instead of %f (ie: %f instead of %f)", relError, theoryError, wkmv.Sum(), total)
+		t.FailNow()
+	}
+	if math.Abs(wkmv.Mean()-49.5)/49.5 > theoryError {
+		t.Errorf("Mean too far off: %f instead of %f", wkmv.Mean(), 49.5)
+		t.FailNow()
+	}
+	assert.Equal(t, wkmv.KMinValues().Cardinality(), wkmv.Cardinality())
+}
+
+func TestWeightedKMinValuesUnionBytes(t *testing.T) {
+	wkmv1 := NewWeightedKMinValues(10, MergeSum)
+	wkmv2 := NewWeightedKMinValues(10, MergeSum)
+	wkmv1.AddHash(1, 1)
+	wkmv1.AddHash(2, 1)
+	wkmv2.AddHash(2, 1)
+	wkmv2.AddHash(3, 1)
+
+	union := wkmv1.Union(wkmv2)
+	assert.Equal(t, union.Cardinality(), 3.0)
+	assert.Equal(t, union.Sum(), 4.0)
+
+	union2, err := WeightedKMinValuesFromBytes(union.Bytes())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, union2, union)
+}
+
+func TestWeightedKMinValuesBounds(t *testing.T) {
+	wkmv := NewWeightedKMinValues(1000, MergeSum)
+	total := 0.0
+	for i := 0; i < 10000; i++ {
+		value := float64(i % 100)
+		total += value
+		wkmv.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))), value)
+	}
+
+	_, lower, upper := wkmv.SumBounds(StdDevs(0.99))
+	if total < lower || total > upper {
+		t.Errorf("Sum bounds [%f, %f] don't contain %f", lower, upper, total)
+	}
+	_, lower, upper = wkmv.MeanBounds(StdDevs(0.99))
+	if 49.5 < lower || 49.5 > upper {
+		t.Errorf("Mean bounds [%f, %f] don't contain %f", lower, upper, 49.5)
+	}
+
+	small := NewWeightedKMinValues(10, MergeSum)
+	small.AddHash(1, 2)
+	small.AddHash(2, 4)
+	sum, lower, upper := small.SumBounds(2)
+	assert.Equal(t, []float64{sum, lower, upper}, []float64{6, 6, 6})
+}
+
+func TestWeightedKMinValuesSmallK(t *testing.T) {
+	for _, k := range []int{1, 2} {
+		wkmv := NewWeightedKMinValues(k, MergeSum)
+		for i := 0; i < 100; i++ {
+			wkmv.AddHash(GetHash([]byte(fmt.Sprintf("%d", i))), float64(i))
+		}
+		assert.Equal(t, wkmv.RelativeError(), 1.0)
+
+		// the bounds have to stay finite so that they can be sent as json
+		bounds := make([]float64, 3)
+		bounds[0], bounds[1], bounds[2] = wkmv.SumBounds(2)
+		_, err := json.Marshal(bounds)
+		assert.Equal(t, err, nil)
+	}
+}
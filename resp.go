@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"github.com/mynameisfiber/gocountme/store"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Largest bulk string, number of arguments and total size of the bulk
+// strings accepted in a RESP command.  Connections sending anything larger are
+// closed.
+const (
+	maxRESPBulk    = 1 << 20
+	maxRESPArgs    = 1 << 16
+	maxRESPCommand = 1 << 26
+)
+
+var (
+	InvalidRESP = errors.New("Protocol error")
+)
+
+// A RESP simple string reply, eg: +OK
+type respStatus string
+
+const respOK respStatus = "OK"
+
+// A command that can be sent to the RESP listener.  Replies are nil (a null
+// bulk string), respStatus, error, int64, float64 or string (bulk strings),
+// or []interface{} of any of these.
+type respCommand struct {
+	minArgs int
+	maxArgs int // -1 for no maximum
+	run     func(args []string) interface{}
+}
+
+var respCommands = map[string]respCommand{
+	"PING":            {0, 1, respPing},
+	"DEL":             {1, -1, respDel},
+	"PFADD":           {1, -1, respPFAdd},
+	"PFCOUNT":         {1, -1, respPFCount},
+	"PFMERGE":         {1, -1, respPFMerge},
+	"KMV.JACCARD":     {2, -1, respJaccard},
+	"KMV.CONTAINMENT": {2, 2, respContainment},
+	"KMV.CORR":        {2, -1, respCorrelation},
+}
+
+// Accepts Redis protocol connections on listener until it is closed
+func ServeRESP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go serveRESPConn(conn)
+	}
+}
+
+// Runs every command sent on conn in order.  Replies are only flushed once
+// there are no more buffered commands so that pipelined commands get their
+// replies written out together.
+func serveRESPConn(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	for {
+		args, err := readRESPCommand(reader)
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			writeRESP(writer, err)
+			writer.Flush()
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		name := strings.ToUpper(args[0])
+		if name == "QUIT" {
+			writeRESP(writer, respOK)
+			writer.Flush()
+			return
+		}
+		if err := writeRESP(writer, runRESPCommand(name, args[1:])); err != nil {
+			return
+		}
+		if reader.Buffered() == 0 {
+			if err := writer.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func runRESPCommand(name string, args []string) interface{} {
+	command, found := respCommands[name]
+	if !found {
+		return fmt.Errorf("unknown command '%s'", strings.ToLower(name))
+	}
+	if len(args) < command.minArgs || (command.maxArgs >= 0 && len(args) > command.maxArgs) {
+		return fmt.Errorf("wrong number of arguments for '%s' command", strings.ToLower(name))
+	}
+
+	// registered like the requests shutdownGate lets through so that nothing
+	// gets sent to the workers once requests have been drained
+	if !beginRequest() {
+		return ServerShuttingDown
+	}
+	defer endRequest()
+	return command.run(args)
+}
+
+// Reads a command sent either as an array of bulk strings, which is what
+// Redis clients send, or as an inline command like `PFCOUNT key` which is
+// handy with telnet
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := readRESPLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > maxRESPArgs {
+		return nil, InvalidRESP
+	}
+	args := make([]string, 0, n)
+	total := 0
+	for i := 0; i < n; i++ {
+		line, err := readRESPLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, InvalidRESP
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxRESPBulk {
+			return nil, InvalidRESP
+		}
+		total += size
+		if total > maxRESPCommand {
+			return nil, InvalidRESP
+		}
+		bulk := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, bulk); err != nil {
+			return nil, err
+		}
+		if !bytes.HasSuffix(bulk, []byte("\r\n")) {
+			return nil, InvalidRESP
+		}
+		args = append(args, string(bulk[:size]))
+	}
+	return args, nil
+}
+
+// Reads a line without its line ending.  Lines longer than the reader's
+// buffer are refused.
+func readRESPLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return "", InvalidRESP
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+func writeRESP(writer *bufio.Writer, reply interface{}) error {
+	var err error
+	switch r := reply.(type) {
+	case nil:
+		_, err = writer.WriteString("$-1\r\n")
+	case respStatus:
+		_, err = fmt.Fprintf(writer, "+%s\r\n", r)
+	case error:
+		message := strings.NewReplacer("\r", " ", "\n", " ").Replace(r.Error())
+		_, err = fmt.Fprintf(writer, "-ERR %s\r\n", message)
+	case int64:
+		_, err = fmt.Fprintf(writer, ":%d\r\n", r)
+	case float64:
+		err = writeRESP(writer, strconv.FormatFloat(r, 'f', -1, 64))
+	case string:
+		_, err = fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(r), r)
+	case []interface{}:
+		_, err = fmt.Fprintf(writer, "*%d\r\n", len(r))
+		for _, element := range r {
+			if err != nil {
+				break
+			}
+			err = writeRESP(writer, element)
+		}
+	default:
+		err = writeRESP(writer, fmt.Errorf("could not format reply"))
+	}
+	return err
+}
+
+// Unions the sources into key, creating key if it doesn't exist
+func respUnion(key string, sources []string) error {
+	resultChan := make(chan store.Result, 1)
+	sketchStore.Do(store.UnionRequest{
+		Into:       key,
+		Sources:    sources,
+		ResultChan: resultChan,
+	})
+	return (<-resultChan).Error
+}
+
+func respPing(args []string) interface{} {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return respStatus("PONG")
+}
+
+// Deletes every key and replies with how many of them existed
+func respDel(args []string) interface{} {
+	deleted := int64(0)
+	resultChan := make(chan store.ChangeResult, 1)
+	for _, key := range args {
+		sketchStore.Do(&store.DeleteExistingRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		})
+		result := <-resultChan
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.Changed {
+			deleted += 1
+		}
+	}
+	return deleted
+}
+
+// Adds every element to the set at the first key.  Like Redis this replies
+// with 1 if the set was created or changed and 0 otherwise.
+func respPFAdd(args []string) interface{} {
+	hashes := make([]uint64, len(args)-1)
+	for i, value := range args[1:] {
+		hashes[i] = store.Hashify([]byte(value))
+	}
+	resultChan := make(chan store.ChangeResult, 1)
+	sketchStore.Do(&store.AddHashesRequest{
+		Key:        args[0],
+		Hashes:     hashes,
+		ResultChan: resultChan,
+	})
+	result := <-resultChan
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.Changed {
+		return int64(1)
+	}
+	return int64(0)
+}
+
+// Replies with the cardinality of the union of every key
+func respPFCount(args []string) interface{} {
+	data, err := sketchStore.GetSets(args)
+	if err != nil {
+		return err
+	}
+	union, err := kminvalues.UnionSketches(data...)
+	if err != nil {
+		return err
+	}
+	return int64(math.Round(union.Cardinality()))
+}
+
+// Stores the union of every key into the first one
+func respPFMerge(args []string) interface{} {
+	if err := respUnion(args[0], args[1:]); err != nil {
+		return err
+	}
+	return respOK
+}
+
+func respThetas(keys []string) ([]*kminvalues.ThetaSketch, error) {
+	data, err := sketchStore.GetSets(keys)
+	if err != nil {
+		return nil, err
+	}
+	return kminvalues.AsThetaSketches(data...)
+}
+
+func respJaccard(args []string) interface{} {
+	thetas, err := respThetas(args)
+	if err != nil {
+		return err
+	}
+	return kminvalues.ThetaJaccard(thetas...)
+}
+
+func respContainment(args []string) interface{} {
+	thetas, err := respThetas(args)
+	if err != nil {
+		return err
+	}
+	return kminvalues.ThetaContainment(thetas[0], thetas[1])
+}
+
+// Replies with a [key1, key2, jaccard] triple for every pair of keys
+func respCorrelation(args []string) interface{} {
+	thetas, err := respThetas(args)
+	if err != nil {
+		return err
+	}
+	N := len(args)
+	matrix := make([]interface{}, 0, N*(N-1)/2)
+	for i := 0; i < N-1; i++ {
+		for j := i + 1; j < N; j++ {
+			jaccard := kminvalues.ThetaJaccard(thetas[i], thetas[j])
+			matrix = append(matrix, []interface{}{args[i], args[j], jaccard})
+		}
+	}
+	return matrix
+}
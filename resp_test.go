@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/bmizerany/assert"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func respArray(args ...string) string {
+	command := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		command += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return command
+}
+
+func TestRESPCommands(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+	defer listener.Close()
+	go ServeRESP(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.Equal(t, err, nil)
+	defer conn.Close()
+
+	commands := []string{
+		respArray("DEL", "_GOTEST_RESP1", "_GOTEST_RESP2", "_GOTEST_RESP3"),
+		respArray("PING"),
+		respArray("pfadd", "_GOTEST_RESP1", "a", "b"),
+		respArray("PFADD", "_GOTEST_RESP1", "a"),
+		respArray("PFADD", "_GOTEST_RESP2", "b", "c"),
+		"PFCOUNT _GOTEST_RESP1 _GOTEST_RESP2\r\n",
+		respArray("KMV.JACCARD", "_GOTEST_RESP1", "_GOTEST_RESP2"),
+		respArray("KMV.CONTAINMENT", "_GOTEST_RESP1", "_GOTEST_RESP2"),
+		respArray("KMV.CORR", "_GOTEST_RESP1", "_GOTEST_RESP2"),
+		respArray("PFMERGE", "_GOTEST_RESP3", "_GOTEST_RESP1", "_GOTEST_RESP2"),
+		respArray("PFCOUNT", "_GOTEST_RESP3"),
+		respArray("PFCOUNT"),
+		respArray("NOPE"),
+		respArray("DEL", "_GOTEST_RESP1", "_GOTEST_RESP2", "_GOTEST_RESP3", "_GOTEST_RESP4"),
+		respArray("QUIT"),
+	}
+	_, err = conn.Write([]byte(strings.Join(commands, "")))
+	assert.Equal(t, err, nil)
+
+	replies, err := io.ReadAll(bufio.NewReader(conn))
+	assert.Equal(t, err, nil)
+	expected := []string{
+		":0\r\n",
+		"+PONG\r\n",
+		":1\r\n",
+		":0\r\n",
+		":1\r\n",
+		":3\r\n",
+		"$18\r\n0.3333333333333333\r\n",
+		"$3\r\n0.5\r\n",
+		"*1\r\n*3\r\n$13\r\n_GOTEST_RESP1\r\n$13\r\n_GOTEST_RESP2\r\n$18\r\n0.3333333333333333\r\n",
+		"+OK\r\n",
+		":3\r\n",
+		"-ERR wrong number of arguments for 'pfcount' command\r\n",
+		"-ERR unknown command 'nope'\r\n",
+		":3\r\n",
+		"+OK\r\n",
+	}
+	assert.Equal(t, string(replies), strings.Join(expected, ""))
+}
+
+func TestRESPProtocolError(t *testing.T) {
+	server, client := net.Pipe()
+	go serveRESPConn(server)
+	defer client.Close()
+
+	go client.Write([]byte("*1\r\n+PING\r\n"))
+	reply, err := bufio.NewReader(client).ReadString('\n')
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reply, "-ERR Protocol error\r\n")
+}
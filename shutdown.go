@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+var (
+	shutdownLock sync.Mutex
+	shuttingDown bool
+	inflight     int
+	drained      chan bool
+	exitChan     = make(chan bool)
+	exitOnce     sync.Once
+)
+
+// Registers a request as being served and returns true, or returns false
+// straight away if the server has started shutting down.  Every request that
+// gets true has to call endRequest once it is done with sketchStore.
+func beginRequest() bool {
+	shutdownLock.Lock()
+	defer shutdownLock.Unlock()
+	if shuttingDown {
+		return false
+	}
+	inflight += 1
+	return true
+}
+
+func endRequest() {
+	shutdownLock.Lock()
+	defer shutdownLock.Unlock()
+	inflight -= 1
+	if shuttingDown && inflight == 0 {
+		close(drained)
+	}
+}
+
+// Wraps a handler so that requests get a 503 once the server has started
+// shutting down and so that drainRequests can wait for the ones being served
+func shutdownGate(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !beginRequest() {
+			HttpError(w, 503, "SHUTTING_DOWN")
+			return
+		}
+		defer endRequest()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Stops new requests from being served and waits for the ones in flight to
+// finish, or for ctx to be done.  Returns false if requests were still being
+// served when ctx was done, which happens with long /ingest streams.  Requests
+// made after this is called are refused before they reach sketchStore, but
+// connections to the protocol servers stay open until those servers are
+// stopped and the compactor keeps running until sketchStore is closed.
+func drainRequests(ctx context.Context) bool {
+	shutdownLock.Lock()
+	if !shuttingDown {
+		shuttingDown = true
+		drained = make(chan bool)
+		if inflight == 0 {
+			close(drained)
+		}
+	}
+	done := drained
+	shutdownLock.Unlock()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Asks the server to shut down.  This is safe to call more than once and from
+// inside of a handler.
+func Exit() {
+	exitOnce.Do(func() {
+		close(exitChan)
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"github.com/bmizerany/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShutdownGate(t *testing.T) {
+	defer func() { shuttingDown = false }()
+
+	started := make(chan bool)
+	release := make(chan bool)
+	handler := shutdownGate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- true
+		<-release
+		HttpResponse(w, 200, "OK")
+	}))
+
+	inflight := httptest.NewRecorder()
+	go handler.ServeHTTP(inflight, httptest.NewRequest("GET", "/", nil))
+	<-started
+
+	drained := make(chan bool)
+	go func() {
+		drainRequests(context.Background())
+		drained <- true
+	}()
+
+	select {
+	case <-drained:
+		t.Errorf("Requests drained while one was still being served")
+		t.FailNow()
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// requests arriving while the drain waits are refused without waiting
+	refusedEarly := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		refusedEarly <- w.Code
+	}()
+	select {
+	case code := <-refusedEarly:
+		assert.Equal(t, code, 503)
+	case <-time.After(time.Second):
+		t.Errorf("Request made during the drain waited for it")
+		t.FailNow()
+	}
+
+	release <- true
+	<-drained
+	assert.Equal(t, inflight.Code, 200)
+
+	refused := httptest.NewRecorder()
+	handler.ServeHTTP(refused, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, refused.Code, 503)
+}
+
+func TestDrainTimeout(t *testing.T) {
+	defer func() { shuttingDown = false }()
+
+	started := make(chan bool)
+	release := make(chan bool)
+	handler := shutdownGate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- true
+		<-release
+		HttpResponse(w, 200, "OK")
+	}))
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, drainRequests(ctx), false)
+
+	// the drain still finishes once the request does
+	release <- true
+	assert.Equal(t, drainRequests(context.Background()), true)
+}
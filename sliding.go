@@ -0,0 +1,152 @@
+package main
+
+import (
+	"github.com/mynameisfiber/gocountme/store"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+func SlidingAddHandler(w http.ResponseWriter, r *http.Request) {
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	key := reqParams.Get("key")
+	if key == "" {
+		HttpError(w, 500, "MISSING_ARG_KEY")
+		return
+	}
+
+	value := reqParams.Get("value")
+	if value == "" {
+		HttpError(w, 500, "MISSING_ARG_VALUE")
+		return
+	}
+
+	seen := time.Now().Unix()
+	if reqParams.Get("ts") != "" {
+		var ok bool
+		seen, ok = timeParam(reqParams, "ts")
+		if !ok {
+			HttpError(w, 500, "INVALID_ARG_TS")
+			return
+		}
+	}
+
+	size, ok := sizeParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_K")
+		return
+	}
+
+	window := int64(*slidingWindow / time.Second)
+	if reqParams.Get("window") != "" {
+		window, err = strconv.ParseInt(reqParams.Get("window"), 10, 64)
+		if err != nil || window < 0 {
+			HttpError(w, 500, "INVALID_ARG_WINDOW")
+			return
+		}
+	}
+
+	resultChan := make(chan store.Result)
+	slidingAddRequest := store.SlidingAddRequest{
+		Key:        key,
+		Hash:       store.Hashify([]byte(value)),
+		Seen:       seen,
+		Size:       size,
+		Window:     window,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(slidingAddRequest)
+	result := <-resultChan
+	close(resultChan)
+	if result.Error == nil {
+		HttpResponse(w, 200, "OK")
+	} else {
+		HttpResponse(w, 500, result.Error.Error())
+	}
+}
+
+func SlidingCardinalityHandler(w http.ResponseWriter, r *http.Request) {
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	key := reqParams.Get("key")
+	if key == "" {
+		HttpError(w, 500, "MISSING_ARG_KEY")
+		return
+	}
+
+	var since int64
+	if reqParams.Get("since") != "" {
+		var ok bool
+		since, ok = timeParam(reqParams, "since")
+		if !ok {
+			HttpError(w, 500, "INVALID_ARG_SINCE")
+			return
+		}
+	} else if reqParams.Get("last") != "" {
+		last, err := strconv.ParseInt(reqParams.Get("last"), 10, 64)
+		if err != nil || last < 0 {
+			HttpError(w, 500, "INVALID_ARG_LAST")
+			return
+		}
+		since = time.Now().Unix() - last
+	}
+
+	confidence, ok := confidenceParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_CONFIDENCE")
+		return
+	}
+
+	resultChan := make(chan store.Result)
+	slidingGetRequest := store.SlidingGetRequest{
+		Key:        key,
+		Since:      since,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(slidingGetRequest)
+	result := <-resultChan
+	close(resultChan)
+	if result.Error == nil {
+		HttpResponse(w, 200, store.SketchEstimate(result.Data, confidence))
+	} else {
+		HttpResponse(w, 500, result.Error.Error())
+	}
+}
+
+func SlidingDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	key := reqParams.Get("key")
+	if key == "" {
+		HttpError(w, 500, "MISSING_ARG_KEY")
+		return
+	}
+
+	resultChan := make(chan store.Result)
+	deleteRequest := store.DeleteRequest{
+		Key:        store.SlidingPrefix + key,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(deleteRequest)
+	result := <-resultChan
+	close(resultChan)
+	if result.Error == nil {
+		HttpResponse(w, 200, "OK")
+	} else {
+		HttpResponse(w, 500, result.Error.Error())
+	}
+}
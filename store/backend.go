@@ -0,0 +1,76 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	UnknownBackend = errors.New("Unknown backend")
+)
+
+// Key-value storage that sets are kept in.  Keys are ordered bytewise so that
+// Iterate can list keys by prefix.  Backends are used by every worker at once
+// and must be safe for concurrent use.
+type Backend interface {
+	// Returns the value stored at key or nil if the key doesn't exist
+	Get(key []byte) ([]byte, error)
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	// Calls f with every key from start onwards, in order, until f returns
+	// false.  key and value are only valid until f returns.
+	Iterate(start []byte, f func(key, value []byte) bool) error
+	// Applies every operation in the batch or none of them
+	Write(batch *Batch) error
+	Close() error
+}
+
+// A list of puts and deletes that a Backend writes all at once
+type Batch struct {
+	ops []batchOp
+}
+
+type batchOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+func (b *Batch) Put(key, value []byte) {
+	b.ops = append(b.ops, batchOp{key: key, value: value})
+}
+
+func (b *Batch) Delete(key []byte) {
+	b.ops = append(b.ops, batchOp{key: key, delete: true})
+}
+
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Opens the backend a Store keeps its sets in, by name.  Backends that need
+// cgo register themselves when they are built.
+var backends = map[string]func(path string, options Options) (Backend, error){
+	"memory": func(string, Options) (Backend, error) { return NewMemoryBackend(), nil },
+	"bolt":   func(path string, _ Options) (Backend, error) { return OpenBolt(boltPath(path)) },
+}
+
+func openBackend(name string, path string, options Options) (Backend, error) {
+	open, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("%w '%s', must be one of %s", UnknownBackend, name, strings.Join(Backends(), ", "))
+	}
+	return open(path, options)
+}
+
+// Lists the names of the backends that were built in
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"errors"
+	"github.com/bmizerany/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Runs the same checks against every backend
+func testBackend(t *testing.T, backend Backend) {
+	defer func() { assert.Equal(t, backend.Close(), nil) }()
+
+	value, err := backend.Get([]byte("missing"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, []byte(nil))
+
+	for _, key := range []string{"b:2", "a:1", "b:1", "c:1"} {
+		assert.Equal(t, backend.Put([]byte(key), []byte("value "+key)), nil)
+	}
+	value, err = backend.Get([]byte("a:1"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(value), "value a:1")
+
+	assert.Equal(t, backend.Delete([]byte("a:1")), nil)
+	value, _ = backend.Get([]byte("a:1"))
+	assert.Equal(t, value, []byte(nil))
+
+	batch := &Batch{}
+	batch.Put([]byte("b:3"), []byte("value b:3"))
+	batch.Delete([]byte("c:1"))
+	assert.Equal(t, backend.Write(batch), nil)
+
+	keys := make([]string, 0)
+	err = backend.Iterate([]byte("b:"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		assert.Equal(t, string(value), "value "+string(key))
+		return len(keys) < 2
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, keys, []string{"b:1", "b:2"})
+
+	keys = keys[:0]
+	backend.Iterate([]byte("b:2"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	assert.Equal(t, keys, []string{"b:2", "b:3"})
+}
+
+func TestMemoryBackend(t *testing.T) {
+	testBackend(t, NewMemoryBackend())
+}
+
+func TestBoltBackend(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gocountme_bolt")
+	assert.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	backend, err := OpenBolt(boltPath(dir))
+	assert.Equal(t, err, nil)
+	testBackend(t, backend)
+
+	// the keys are still there once the file is opened again
+	backend, err = OpenBolt(filepath.Join(dir, "gocountme.bolt"))
+	assert.Equal(t, err, nil)
+	value, _ := backend.Get([]byte("b:3"))
+	assert.Equal(t, string(value), "value b:3")
+	backend.Close()
+}
+
+func TestOpenBackend(t *testing.T) {
+	s, err := Open("", Options{Backend: "memory"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, s.Add("key", "value"), nil)
+	assert.Equal(t, s.Close(), nil)
+
+	_, err = Open("", Options{Backend: "floppy"})
+	assert.T(t, errors.Is(err, UnknownBackend))
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Name of the bucket every key is stored in
+var boltBucket = []byte("gocountme")
+
+// Stores sets in a BoltDB file.  Bolt is written in pure Go, so unlike LevelDB
+// it can be used in binaries built without cgo.  Every write is its own
+// transaction and is synced to disk, so the write-back cache makes a much
+// bigger difference than it does with LevelDB.
+type BoltBackend struct {
+	db *bolt.DB
+}
+
+// Opens the Bolt file at path, creating it if it doesn't exist
+func OpenBolt(path string) (*BoltBackend, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltBackend{db}, nil
+}
+
+// The database location can be a directory, as it is for LevelDB, in which
+// case the Bolt file is kept inside of it
+func boltPath(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Join(path, "gocountme.bolt")
+	}
+	return path
+}
+
+func (bb *BoltBackend) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := bb.db.View(func(tx *bolt.Tx) error {
+		value = copyBytes(tx.Bucket(boltBucket).Get(key))
+		return nil
+	})
+	return value, err
+}
+
+func (bb *BoltBackend) Put(key, value []byte) error {
+	return bb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+}
+
+func (bb *BoltBackend) Delete(key []byte) error {
+	return bb.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+}
+
+// Iterates within a single read transaction, so f sees the keys as they were
+// when Iterate was called
+func (bb *BoltBackend) Iterate(start []byte, f func(key, value []byte) bool) error {
+	return bb.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltBucket).Cursor()
+		for key, value := cursor.Seek(start); key != nil; key, value = cursor.Next() {
+			if !f(key, value) {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+func (bb *BoltBackend) Write(batch *Batch) error {
+	return bb.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, op := range batch.ops {
+			var err error
+			if op.delete {
+				err = bucket.Delete(op.key)
+			} else {
+				err = bucket.Put(op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bb *BoltBackend) Close() error {
+	return bb.db.Close()
+}
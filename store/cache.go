@@ -0,0 +1,128 @@
+package store
+
+import (
+	"container/list"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"sync"
+)
+
+// An LRU of decoded sets that sits in front of the backend.  Writes only
+// update the cache and mark the set as dirty.  Dirty sets get written to the
+// backend when they are evicted or when the cache is flushed.  Sets are copied
+// going in and out of the cache so that a cached set is never changed by a
+// request.
+type SketchCache struct {
+	sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key    string
+	sketch kminvalues.Sketch
+	dirty  bool
+}
+
+func NewSketchCache(size int) *SketchCache {
+	return &SketchCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (sc *SketchCache) Get(key string) (kminvalues.Sketch, bool) {
+	sc.Lock()
+	defer sc.Unlock()
+
+	elem, found := sc.entries[key]
+	if !found {
+		return nil, false
+	}
+	sc.lru.MoveToFront(elem)
+	return kminvalues.CopySketch(elem.Value.(*cacheEntry).sketch), true
+}
+
+// Stores a set in the cache.  Clean sets (ie: ones that were just read from
+// the database) never replace a set that is already cached.  If the cache is
+// full, the least recently used sets are evicted and the dirty ones are
+// written to the database.
+func (sc *SketchCache) Put(database Backend, key string, sketch kminvalues.Sketch, dirty bool) error {
+	sc.Lock()
+	defer sc.Unlock()
+
+	if elem, found := sc.entries[key]; found {
+		sc.lru.MoveToFront(elem)
+		if dirty {
+			entry := elem.Value.(*cacheEntry)
+			entry.sketch = kminvalues.CopySketch(sketch)
+			entry.dirty = true
+		}
+	} else {
+		elem = sc.lru.PushFront(&cacheEntry{key, kminvalues.CopySketch(sketch), dirty})
+		sc.entries[key] = elem
+	}
+
+	if sc.lru.Len() <= sc.size {
+		return nil
+	}
+
+	wb := &Batch{}
+	evicted := make([]*list.Element, 0, sc.lru.Len()-sc.size)
+	for elem := sc.lru.Back(); len(evicted) < cap(evicted); elem = elem.Prev() {
+		entry := elem.Value.(*cacheEntry)
+		if entry.dirty {
+			wb.Put([]byte(entry.key), entry.sketch.Bytes())
+		}
+		evicted = append(evicted, elem)
+	}
+	err := database.Write(wb)
+	if err != nil {
+		return err
+	}
+	for _, elem := range evicted {
+		delete(sc.entries, elem.Value.(*cacheEntry).key)
+		sc.lru.Remove(elem)
+	}
+	return nil
+}
+
+func (sc *SketchCache) Remove(key string) {
+	sc.Lock()
+	defer sc.Unlock()
+
+	if elem, found := sc.entries[key]; found {
+		delete(sc.entries, key)
+		sc.lru.Remove(elem)
+	}
+}
+
+// Writes every dirty set to the backend with a single Batch
+func (sc *SketchCache) Flush(database Backend) error {
+	sc.Lock()
+	defer sc.Unlock()
+
+	wb := &Batch{}
+
+	dirty := make([]*cacheEntry, 0)
+	for elem := sc.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*cacheEntry)
+		if entry.dirty {
+			wb.Put([]byte(entry.key), entry.sketch.Bytes())
+			dirty = append(dirty, entry)
+		}
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	err := database.Write(wb)
+	if err != nil {
+		return err
+	}
+	for _, entry := range dirty {
+		entry.dirty = false
+	}
+	return nil
+}
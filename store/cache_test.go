@@ -0,0 +1,176 @@
+package store
+
+import (
+	"errors"
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"testing"
+)
+
+func TestSketchCache(t *testing.T) {
+	db := NewMemoryBackend()
+	keys := []string{"_GOTEST_CACHE1", "_GOTEST_CACHE2", "_GOTEST_CACHE3"}
+
+	cache := NewSketchCache(2)
+	for _, key := range keys[:2] {
+		kmv := kminvalues.NewKMinValues(10)
+		kmv.AddHash(1)
+		err := cache.Put(db, key, kmv, true)
+		assert.Equal(t, err, nil)
+
+		kmv.AddHash(2)
+		cached, found := cache.Get(key)
+		assert.T(t, found)
+		assert.Equal(t, cached.(*kminvalues.KMinValues).Len(), 1)
+
+		data, _ := db.Get([]byte(key))
+		assert.Equal(t, len(data), 0)
+	}
+
+	// Using the first key makes the second one the least recently used
+	cache.Get(keys[0])
+	err := cache.Put(db, keys[2], kminvalues.NewKMinValues(10), false)
+	assert.Equal(t, err, nil)
+	_, found := cache.Get(keys[1])
+	assert.T(t, !found)
+	data, _ := db.Get([]byte(keys[1]))
+	assert.NotEqual(t, len(data), 0)
+	data, _ = db.Get([]byte(keys[0]))
+	assert.Equal(t, len(data), 0)
+
+	err = cache.Flush(db)
+	assert.Equal(t, err, nil)
+	data, _ = db.Get([]byte(keys[0]))
+	assert.NotEqual(t, len(data), 0)
+	data, _ = db.Get([]byte(keys[2]))
+	assert.Equal(t, len(data), 0)
+}
+
+func TestDBWithSketchCache(t *testing.T) {
+	SetupDBOptions(Options{Workers: 4, CacheSize: 1})
+	defer CloseDB()
+
+	keys := []string{"_GOTEST_CACHEDB1", "_GOTEST_CACHEDB2"}
+	resultChan := make(chan Result)
+	clean := func() {
+		for _, key := range keys {
+			testStore.Do(DeleteRequest{
+				Key:        key,
+				ResultChan: resultChan,
+			})
+			<-resultChan
+		}
+	}
+	clean()
+	defer clean()
+
+	for i := 0; i < 100; i++ {
+		testStore.Do(AddHashRequest{
+			Key:        keys[i%2],
+			Hash:       uint64(i + 1),
+			Size:       100,
+			ResultChan: resultChan,
+		})
+		result := <-resultChan
+		assert.Equal(t, result.Error, nil)
+	}
+
+	for _, key := range keys {
+		testStore.Do(GetRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		})
+		result := <-resultChan
+		assert.Equal(t, result.Error, nil)
+		assert.Equal(t, result.Data.(*kminvalues.KMinValues).Len(), 50)
+	}
+}
+
+func TestDBBulkAddWithSketchCache(t *testing.T) {
+	SetupDBOptions(Options{Workers: 1, CacheSize: 10})
+	defer CloseDB()
+
+	keys := []string{"_GOTEST_CACHEBULK1", "_GOTEST_CACHEBULK2"}
+	resultChan := make(chan Result)
+
+	// A dirty set that the bulk add builds on
+	testStore.Do(AddHashRequest{
+		Key:        keys[0],
+		Hash:       1,
+		Size:       100,
+		ResultChan: resultChan,
+	})
+	assert.Equal(t, (<-resultChan).Error, nil)
+
+	items := make([]KeyHash, 0, 20)
+	for i := 0; i < 20; i++ {
+		items = append(items, KeyHash{keys[i%2], uint64(i + 2)})
+	}
+	testStore.Do(BulkAddRequest{
+		Items:      items,
+		Size:       100,
+		ResultChan: resultChan,
+	})
+	assert.Equal(t, (<-resultChan).Error, nil)
+
+	// The bulk add is written straight to the backend without a flush
+	for i, key := range keys {
+		data, err := testStore.db.Get([]byte(key))
+		assert.Equal(t, err, nil)
+		kmv, err := kminvalues.KMinValuesFromBytes(data)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, kmv.Len(), 11-i)
+	}
+}
+
+// Fails every Write while failWrites is set
+type failingBackend struct {
+	*MemoryBackend
+	failWrites bool
+}
+
+func (fb *failingBackend) Write(batch *Batch) error {
+	if fb.failWrites {
+		return errors.New("write failed")
+	}
+	return fb.MemoryBackend.Write(batch)
+}
+
+func TestDBFailedBulkAddWithSketchCache(t *testing.T) {
+	backend := &failingBackend{MemoryBackend: NewMemoryBackend()}
+	testStore = New(backend, Options{Workers: 1, CacheSize: 10})
+	defer CloseDB()
+
+	key := "_GOTEST_CACHEBULKFAIL"
+	resultChan := make(chan Result)
+	testStore.Do(AddHashRequest{
+		Key:        key,
+		Hash:       1,
+		ResultChan: resultChan,
+	})
+	assert.Equal(t, (<-resultChan).Error, nil)
+
+	backend.failWrites = true
+	testStore.Do(BulkAddRequest{
+		Items:      []KeyHash{{key, 2}},
+		ResultChan: resultChan,
+	})
+	assert.NotEqual(t, (<-resultChan).Error, nil)
+	backend.failWrites = false
+
+	// The cache still holds the dirty set from before the failed bulk add
+	testStore.Do(GetRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	})
+	result := <-resultChan
+	assert.Equal(t, result.Error, nil)
+	assert.Equal(t, result.Data.(*kminvalues.KMinValues).Len(), 1)
+
+	assert.Equal(t, testStore.cache.Flush(testStore.db), nil)
+	data, err := backend.Get([]byte(key))
+	assert.Equal(t, err, nil)
+	kmv, err := kminvalues.KMinValuesFromBytes(data)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, kmv.Len(), 1)
+}
@@ -0,0 +1,567 @@
+package store
+
+import (
+	"errors"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Number of locks that keys are striped over.  Any request that writes to a
+// key holds that key's lock so that concurrent workers can't read the
+// same set and then overwrite each other's changes.
+const nKeyLocks = 1024
+
+// Keys starting with this prefix hold data gocountme keeps for itself, such as
+// expiry times, and are hidden from key listings
+const internalPrefix = "\x00"
+
+var (
+	NoKeySpecified = errors.New("No Key supplied for db Request")
+	NotImplemented = errors.New("Not Implemented")
+	KeyNotFound    = errors.New("Key not found")
+	StoreClosed    = errors.New("Store is closed")
+)
+
+type Result struct {
+	Key   string
+	Data  kminvalues.Sketch
+	Error error
+}
+
+// A request run by one of the store's workers, see Store.Do
+type RequestCommand interface {
+	Execute(s *Store) (kminvalues.Sketch, error)
+	WriteResult(result Result)
+}
+
+type GetRequest struct {
+	Key        string
+	ResultChan chan Result
+}
+
+type SetRequest struct {
+	Key        string
+	Kmv        kminvalues.Sketch
+	ResultChan chan Result
+}
+
+type DeleteRequest struct {
+	Key        string
+	ResultChan chan Result
+}
+
+// Adds a hash to the set stored at Key.  If the set doesn't exist yet it is
+// created as a Type sketch of the given Size.
+type AddHashRequest struct {
+	Key        string
+	Hash       uint64
+	Size       int
+	Type       kminvalues.SketchType
+	TTL        time.Duration
+	ResultChan chan Result
+}
+
+type KeyHash struct {
+	Key  string
+	Hash uint64
+}
+
+type BulkAddRequest struct {
+	Items      []KeyHash
+	Size       int
+	ResultChan chan Result
+}
+
+type ResizeRequest struct {
+	Key        string
+	NewSize    int
+	ResultChan chan Result
+}
+
+// Unions the Sources sets into the Into set, creating it if it doesn't exist.
+// Unlike MergeRequest the sources are kept.
+type UnionRequest struct {
+	Into       string
+	Sources    []string
+	ResultChan chan Result
+}
+
+// Result of the requests that report whether they changed the set at Key
+type ChangeResult struct {
+	Key     string
+	Changed bool
+	Error   error
+}
+
+// Adds Hashes to the set at Key, creating it if it doesn't exist.  Changed is
+// set if the set was created or any of its hashes changed.  This is a pointer
+// type since Execute keeps Changed around for WriteResult.
+type AddHashesRequest struct {
+	Key        string
+	Hashes     []uint64
+	ResultChan chan ChangeResult
+	result     ChangeResult
+}
+
+// Deletes the set at Key with Changed set if there was one to delete.  This is
+// a pointer type since Execute keeps Changed around for WriteResult.
+type DeleteExistingRequest struct {
+	Key        string
+	ResultChan chan ChangeResult
+	result     ChangeResult
+}
+
+type KeyInfo struct {
+	Key         string  `json:"key"`
+	Type        string  `json:"type,omitempty"`
+	Cardinality float64 `json:"cardinality,omitempty"`
+	K           int     `json:"k,omitempty"`
+	Exact       bool    `json:"exact,omitempty"`
+}
+
+type KeysResult struct {
+	Keys   []KeyInfo `json:"keys"`
+	Cursor string    `json:"cursor"`
+	Error  error     `json:"-"`
+}
+
+// Lists up to Limit keys starting with Prefix that come after Cursor.  This is
+// a pointer type since Execute keeps the listing around for WriteResult.
+type KeysRequest struct {
+	Prefix     string
+	Cursor     string
+	Limit      int
+	Details    bool
+	ResultChan chan KeysResult
+	result     KeysResult
+}
+
+func (gr GetRequest) WriteResult(result Result) {
+	result.Key = gr.Key
+	gr.ResultChan <- result
+}
+func (sr SetRequest) WriteResult(result Result) {
+	result.Key = sr.Key
+	sr.ResultChan <- result
+}
+func (dr DeleteRequest) WriteResult(result Result) {
+	result.Key = dr.Key
+	dr.ResultChan <- result
+}
+func (ahr AddHashRequest) WriteResult(result Result) {
+	result.Key = ahr.Key
+	ahr.ResultChan <- result
+}
+func (bar BulkAddRequest) WriteResult(result Result) {
+	bar.ResultChan <- result
+}
+func (ahr *AddHashesRequest) WriteResult(result Result) {
+	ahr.result.Key = ahr.Key
+	ahr.result.Error = result.Error
+	ahr.ResultChan <- ahr.result
+}
+func (der *DeleteExistingRequest) WriteResult(result Result) {
+	der.result.Key = der.Key
+	der.result.Error = result.Error
+	der.ResultChan <- der.result
+}
+func (kr *KeysRequest) WriteResult(result Result) {
+	kr.result.Error = result.Error
+	kr.ResultChan <- kr.result
+}
+func (rr ResizeRequest) WriteResult(result Result) {
+	result.Key = rr.Key
+	rr.ResultChan <- result
+}
+func (ur UnionRequest) WriteResult(result Result) {
+	result.Key = ur.Into
+	ur.ResultChan <- result
+}
+
+func (gr GetRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if gr.Key == "" {
+		return nil, NoKeySpecified
+	}
+	defer s.lockKeys(gr.Key)()
+
+	sketch, err := s.getSketch(gr.Key)
+	if err != nil {
+		return nil, err
+	}
+	if sketch == nil {
+		return kminvalues.NewKMinValues(s.KeySize(gr.Key)), KeyNotFound
+	}
+	return sketch, nil
+}
+
+func (sr SetRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if sr.Key == "" {
+		return nil, NoKeySpecified
+	}
+	defer s.lockKeys(sr.Key)()
+
+	err := s.putSketch(sr.Key, sr.Kmv)
+	if err != nil {
+		return nil, err
+	}
+	wb := &Batch{}
+	if indexBucket(wb, sr.Key) {
+		err = s.db.Write(wb)
+	}
+	return sr.Kmv, err
+}
+
+func (dr DeleteRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if dr.Key == "" {
+		return nil, NoKeySpecified
+	}
+	defer s.lockKeys(dr.Key)()
+
+	if s.cache != nil {
+		s.cache.Remove(dr.Key)
+	}
+	wb := &Batch{}
+	wb.Delete([]byte(dr.Key))
+	wb.Delete(expireKey(dr.Key))
+	wb.Delete(bucketIndexKey(dr.Key))
+	err := s.db.Write(wb)
+
+	return nil, err
+}
+
+func (der *DeleteExistingRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if der.Key == "" {
+		return nil, NoKeySpecified
+	}
+	defer s.lockKeys(der.Key)()
+
+	sketch, err := s.getSketch(der.Key)
+	if err != nil || sketch == nil {
+		return nil, err
+	}
+	if s.cache != nil {
+		s.cache.Remove(der.Key)
+	}
+	wb := &Batch{}
+	wb.Delete([]byte(der.Key))
+	wb.Delete(expireKey(der.Key))
+	wb.Delete(bucketIndexKey(der.Key))
+	err = s.db.Write(wb)
+	der.result.Changed = err == nil
+	return nil, err
+}
+
+func (ahr AddHashRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if ahr.Key == "" {
+		return nil, NoKeySpecified
+	}
+	defer s.lockKeys(ahr.Key)()
+
+	sketch, created, err := s.loadOrCreate(ahr.Key, ahr.Size, ahr.Type)
+	if err != nil {
+		return nil, err
+	}
+	sketch.AddHash(ahr.Hash)
+
+	err = s.putSketch(ahr.Key, sketch)
+	if err == nil && created {
+		err = s.setExpire(ahr.Key, ahr.TTL)
+	}
+	return sketch, err
+}
+
+// Adds all of the hashes to their sets and writes every set that changed,
+// along with the expiry times and bucket index entries of new keys, with a
+// single Batch so that either
+// all or none of the additions are stored.  The sets are written through the
+// sketch cache, which is then left holding the clean sets.
+func (bar BulkAddRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	keys := make([]string, len(bar.Items))
+	for i, item := range bar.Items {
+		if item.Key == "" {
+			return nil, NoKeySpecified
+		}
+		keys[i] = item.Key
+	}
+	defer s.lockKeys(keys...)()
+
+	sketches := make(map[string]kminvalues.Sketch)
+	wb := &Batch{}
+	now := time.Now()
+	for _, item := range bar.Items {
+		sketch, found := sketches[item.Key]
+		if !found {
+			var isNew bool
+			var err error
+			sketch, isNew, err = s.loadOrCreate(item.Key, bar.Size, kminvalues.TypeKMV)
+			if err != nil {
+				return nil, err
+			}
+			sketches[item.Key] = sketch
+			if isNew {
+				s.initKey(wb, item.Key, 0, now)
+			}
+		}
+		sketch.AddHash(item.Hash)
+	}
+
+	// The sets came from getSketch, which hands out copies of cached sets, so
+	// if the write fails the cache still holds the sets as they were
+	for key, sketch := range sketches {
+		wb.Put([]byte(key), sketch.Bytes())
+	}
+	err := s.db.Write(wb)
+	if err != nil || s.cache == nil {
+		return nil, err
+	}
+	// Every old copy is dropped before any new one is cached, since putting
+	// can fail while evicting and a dirty old copy left behind would later be
+	// flushed over the new set
+	for key := range sketches {
+		s.cache.Remove(key)
+	}
+	for key, sketch := range sketches {
+		err = s.cache.Put(s.db, key, sketch, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (ahr *AddHashesRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if ahr.Key == "" {
+		return nil, NoKeySpecified
+	}
+	defer s.lockKeys(ahr.Key)()
+
+	sketch, created, err := s.loadOrCreate(ahr.Key, 0, kminvalues.TypeKMV)
+	if err != nil {
+		return nil, err
+	}
+	changed := created
+	for _, hash := range ahr.Hashes {
+		if sketch.AddHash(hash) {
+			changed = true
+		}
+	}
+	if !changed {
+		return sketch, nil
+	}
+
+	err = s.putSketch(ahr.Key, sketch)
+	if err == nil && created {
+		err = s.setExpire(ahr.Key, 0)
+	}
+	ahr.result.Changed = err == nil
+	return sketch, err
+}
+
+func (rr ResizeRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if rr.Key == "" {
+		return nil, NoKeySpecified
+	}
+	defer s.lockKeys(rr.Key)()
+
+	sketch, err := s.getSketch(rr.Key)
+	if err != nil {
+		return nil, err
+	}
+	if sketch == nil {
+		return nil, KeyNotFound
+	}
+	kmv, ok := sketch.(*kminvalues.KMinValues)
+	if !ok {
+		return nil, kminvalues.UnsupportedSketchType
+	}
+
+	err = kmv.Resize(rr.NewSize)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.putSketch(rr.Key, kmv)
+	return kmv, err
+}
+
+// Reads and writes Into while holding the locks of every key involved, so that
+// additions to Into made at the same time aren't lost
+func (ur UnionRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if ur.Into == "" {
+		return nil, NoKeySpecified
+	}
+	for _, key := range ur.Sources {
+		if key == "" {
+			return nil, NoKeySpecified
+		}
+	}
+	defer s.lockKeys(append(ur.Sources, ur.Into)...)()
+
+	into, created, err := s.loadOrCreate(ur.Into, 0, kminvalues.TypeKMV)
+	if err != nil {
+		return nil, err
+	}
+	sketches := make([]kminvalues.Sketch, 0, len(ur.Sources)+1)
+	if !created {
+		sketches = append(sketches, into)
+	}
+	for _, key := range ur.Sources {
+		sketch, err := s.getSketch(key)
+		if err != nil {
+			return nil, err
+		}
+		if sketch != nil {
+			sketches = append(sketches, sketch)
+		}
+	}
+
+	union := into
+	if len(sketches) > 0 {
+		union, err = kminvalues.UnionSketches(sketches...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = s.putSketch(ur.Into, union)
+	if err == nil && created {
+		err = s.setExpire(ur.Into, 0)
+	}
+	return union, err
+}
+
+func (kr *KeysRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	// Sets that only live in the cache wouldn't show up while iterating
+	if s.cache != nil {
+		err := s.cache.Flush(s.db)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start, cursor := kr.Prefix, ""
+	if kr.Cursor > kr.Prefix {
+		start, cursor = kr.Cursor, kr.Cursor
+	}
+
+	var err error
+	kr.result.Keys = make([]KeyInfo, 0, kr.Limit)
+	iterErr := s.db.Iterate([]byte(start), func(keyBytes, value []byte) bool {
+		key := string(keyBytes)
+		if key == cursor {
+			return true
+		}
+		if !strings.HasPrefix(key, kr.Prefix) {
+			return false
+		}
+		if strings.HasPrefix(key, internalPrefix) {
+			return true
+		}
+		if len(kr.result.Keys) == kr.Limit {
+			kr.result.Cursor = kr.result.Keys[kr.Limit-1].Key
+			return false
+		}
+
+		info := KeyInfo{Key: key}
+		if kr.Details {
+			var sketch kminvalues.Sketch
+			sketch, err = kminvalues.SketchFromBytes(value)
+			if err != nil {
+				return false
+			}
+			info.Type = sketch.Type().String()
+			info.Cardinality = sketch.Cardinality()
+			info.K = sketch.MaxSize()
+			info.Exact = sketch.Exact()
+		}
+		kr.result.Keys = append(kr.result.Keys, info)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, iterErr
+}
+
+// Locks every key given and returns a function that unlocks them again.  The
+// locks are always taken in the same order so that requests touching several
+// keys can't deadlock each other.
+func (s *Store) lockKeys(keys ...string) func() {
+	idxs := make([]int, 0, len(keys))
+	seen := make(map[int]bool, len(keys))
+	for _, key := range keys {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		idx := int(h.Sum32() % nKeyLocks)
+		if !seen[idx] {
+			seen[idx] = true
+			idxs = append(idxs, idx)
+		}
+	}
+	sort.Ints(idxs)
+
+	for _, idx := range idxs {
+		s.keyLocks[idx].Lock()
+	}
+	return func() {
+		for _, idx := range idxs {
+			s.keyLocks[idx].Unlock()
+		}
+	}
+}
+
+// Reads the set stored under key, going through the sketch cache if there is
+// one.  If the key doesn't exist a nil set is returned.
+func (s *Store) getSketch(key string) (kminvalues.Sketch, error) {
+	if s.cache != nil {
+		if sketch, found := s.cache.Get(key); found {
+			return sketch, nil
+		}
+	}
+
+	data, err := s.db.Get([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	sketch, err := kminvalues.SketchFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	if s.cache != nil {
+		err = s.cache.Put(s.db, key, sketch, false)
+	}
+	return sketch, err
+}
+
+// Stores the set under key.  With a sketch cache the set is only written to
+// the database once it gets evicted or the cache is flushed.
+func (s *Store) putSketch(key string, sketch kminvalues.Sketch) error {
+	if s.cache != nil {
+		return s.cache.Put(s.db, key, sketch, true)
+	}
+	return s.db.Put([]byte(key), sketch.Bytes())
+}
+
+// Reads the set stored under key.  If the key doesn't exist a new sketch of
+// the given type is created with the given size, or with the configured size
+// for the key if size is 0, and true is returned.
+func (s *Store) loadOrCreate(key string, size int, sketchType kminvalues.SketchType) (kminvalues.Sketch, bool, error) {
+	sketch, err := s.getSketch(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if sketch == nil {
+		if size <= 0 {
+			size = s.KeySize(key)
+		}
+		sketch, err = kminvalues.NewSketch(sketchType, size)
+		return sketch, true, err
+	}
+	return sketch, false, nil
+}
@@ -0,0 +1,396 @@
+package store
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"math/rand"
+	"testing"
+)
+
+func GetRandHash() uint64 {
+	hash := uint64(rand.Int63())
+	if rand.Intn(2) == 0 {
+		hash += 1 << 63
+	}
+	return hash
+}
+
+func TestDB(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	key := "_GOTEST_TESTDB"
+	resultChan := make(chan Result)
+
+	clean := func() {
+		delRequest := DeleteRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		}
+		testStore.Do(delRequest)
+		<-resultChan
+	}
+	clean()
+	defer clean()
+
+	kmv := kminvalues.NewKMinValues(50)
+
+	for i := 0; i < 100; i++ {
+		kmv.AddHash(GetRandHash())
+	}
+
+	origCard := kmv.Cardinality()
+
+	setRequest := SetRequest{
+		Key:        key,
+		Kmv:        kmv,
+		ResultChan: resultChan,
+	}
+	testStore.Do(setRequest)
+	result := <-resultChan
+	assert.Equal(t, result.Error, nil)
+
+	for i := 0; i < 50; i++ {
+		addHashRequest := AddHashRequest{
+			Key:        key,
+			Hash:       GetRandHash(),
+			ResultChan: resultChan,
+		}
+		testStore.Do(addHashRequest)
+		result = <-resultChan
+		assert.Equal(t, result.Error, nil)
+	}
+
+	newKmv := result.Data
+	if newKmv.Cardinality() <= origCard {
+		t.Errorf("Cardinality did not increase after 'AddHashRequest' operations")
+		t.FailNow()
+	}
+}
+
+func TestDBResize(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	key := "_GOTEST_TESTDBRESIZE"
+	resultChan := make(chan Result)
+
+	clean := func() {
+		delRequest := DeleteRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		}
+		testStore.Do(delRequest)
+		<-resultChan
+	}
+	clean()
+	defer clean()
+
+	resizeRequest := ResizeRequest{
+		Key:        key,
+		NewSize:    10,
+		ResultChan: resultChan,
+	}
+	testStore.Do(resizeRequest)
+	result := <-resultChan
+	assert.Equal(t, result.Error, KeyNotFound)
+
+	kmv := kminvalues.NewKMinValues(50)
+	for i := 0; i < 100; i++ {
+		kmv.AddHash(GetRandHash())
+	}
+	setRequest := SetRequest{
+		Key:        key,
+		Kmv:        kmv,
+		ResultChan: resultChan,
+	}
+	testStore.Do(setRequest)
+	result = <-resultChan
+	assert.Equal(t, result.Error, nil)
+
+	testStore.Do(resizeRequest)
+	result = <-resultChan
+	assert.Equal(t, result.Error, nil)
+
+	getRequest := GetRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	}
+	testStore.Do(getRequest)
+	result = <-resultChan
+	assert.Equal(t, result.Error, nil)
+	assert.Equal(t, result.Data.(*kminvalues.KMinValues).Len(), 10)
+	assert.Equal(t, result.Data.(*kminvalues.KMinValues).GetHash(0), kmv.GetHash(kmv.Len()-10))
+}
+
+func TestDBBulkAdd(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	keys := []string{"_GOTEST_TESTDBBULK1", "_GOTEST_TESTDBBULK2"}
+	resultChan := make(chan Result)
+
+	clean := func() {
+		for _, key := range keys {
+			delRequest := DeleteRequest{
+				Key:        key,
+				ResultChan: resultChan,
+			}
+			testStore.Do(delRequest)
+			<-resultChan
+		}
+	}
+	clean()
+	defer clean()
+
+	items := make([]KeyHash, 0, 30)
+	for i := 0; i < 30; i++ {
+		items = append(items, KeyHash{keys[i%2], GetRandHash()})
+	}
+	bulkAddRequest := BulkAddRequest{
+		Items:      items,
+		Size:       50,
+		ResultChan: resultChan,
+	}
+	testStore.Do(bulkAddRequest)
+	result := <-resultChan
+	assert.Equal(t, result.Error, nil)
+
+	for _, key := range keys {
+		getRequest := GetRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		}
+		testStore.Do(getRequest)
+		result = <-resultChan
+		assert.Equal(t, result.Error, nil)
+		assert.Equal(t, result.Data.(*kminvalues.KMinValues).Len(), 15)
+	}
+
+	bulkAddRequest.Items = append(bulkAddRequest.Items, KeyHash{"", GetRandHash()})
+	testStore.Do(bulkAddRequest)
+	result = <-resultChan
+	assert.Equal(t, result.Error, NoKeySpecified)
+}
+
+func TestDBConcurrentAddHash(t *testing.T) {
+	nWorkers := 8
+	SetupDBWorkers(nWorkers)
+	defer CloseDB()
+
+	key := "_GOTEST_TESTDBCONCURRENT"
+	resultChan := make(chan Result)
+
+	clean := func() {
+		delRequest := DeleteRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		}
+		testStore.Do(delRequest)
+		<-resultChan
+	}
+	clean()
+	defer clean()
+
+	nHashes := 200
+	done := make(chan bool)
+	for i := 0; i < nWorkers; i++ {
+		go func(i int) {
+			resultChan := make(chan Result)
+			for j := 0; j < nHashes; j++ {
+				testStore.Do(AddHashRequest{
+					Key:        key,
+					Hash:       uint64(i*nHashes + j + 1),
+					Size:       2 * nWorkers * nHashes,
+					ResultChan: resultChan,
+				})
+				<-resultChan
+			}
+			for j := 0; j < nHashes; j++ {
+				testStore.Do(BulkAddRequest{
+					Items:      []KeyHash{{key, uint64((nWorkers+i)*nHashes + j + 1)}},
+					ResultChan: resultChan,
+				})
+				<-resultChan
+			}
+			done <- true
+		}(i)
+	}
+	for i := 0; i < nWorkers; i++ {
+		<-done
+	}
+
+	getRequest := GetRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	}
+	testStore.Do(getRequest)
+	result := <-resultChan
+	assert.Equal(t, result.Error, nil)
+	assert.Equal(t, result.Data.(*kminvalues.KMinValues).Len(), 2*nWorkers*nHashes)
+	assert.Equal(t, result.Data.(*kminvalues.KMinValues).GetHash(0), uint64(2*nWorkers*nHashes))
+}
+
+func TestDBConcurrentUnion(t *testing.T) {
+	nWorkers := 4
+	SetupDBWorkers(nWorkers)
+	defer CloseDB()
+
+	key := "_GOTEST_TESTDBUNION"
+	source := "_GOTEST_TESTDBUNIONSRC"
+	resultChan := make(chan Result)
+	clean := func() {
+		for _, k := range []string{key, source} {
+			testStore.Do(DeleteRequest{
+				Key:        k,
+				ResultChan: resultChan,
+			})
+			<-resultChan
+		}
+	}
+	clean()
+	defer clean()
+
+	nHashes := 200
+	testStore.Do(AddHashRequest{
+		Key:        source,
+		Hash:       uint64(nHashes + 1),
+		ResultChan: resultChan,
+	})
+	<-resultChan
+
+	// unions into key while hashes are being added to it shouldn't lose any
+	// of the additions
+	done := make(chan bool)
+	go func() {
+		resultChan := make(chan Result)
+		for j := 0; j < nHashes; j++ {
+			testStore.Do(AddHashRequest{
+				Key:        key,
+				Hash:       uint64(j + 1),
+				ResultChan: resultChan,
+			})
+			<-resultChan
+		}
+		done <- true
+	}()
+	go func() {
+		resultChan := make(chan Result)
+		for j := 0; j < nHashes; j++ {
+			testStore.Do(UnionRequest{
+				Into:       key,
+				Sources:    []string{source},
+				ResultChan: resultChan,
+			})
+			assert.Equal(t, (<-resultChan).Error, nil)
+		}
+		done <- true
+	}()
+	<-done
+	<-done
+
+	testStore.Do(GetRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	})
+	result := <-resultChan
+	assert.Equal(t, result.Error, nil)
+	assert.Equal(t, result.Data.(*kminvalues.KMinValues).Len(), nHashes+1)
+}
+
+func TestDBChangeRequests(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	key := "_GOTEST_TESTDBCHANGE"
+	resultChan := make(chan ChangeResult)
+	changed := func(request RequestCommand) bool {
+		testStore.Do(request)
+		result := <-resultChan
+		assert.Equal(t, result.Error, nil)
+		return result.Changed
+	}
+
+	assert.Equal(t, changed(&AddHashesRequest{Key: key, ResultChan: resultChan}), true)
+	assert.Equal(t, changed(&AddHashesRequest{Key: key, ResultChan: resultChan}), false)
+	assert.Equal(t, changed(&AddHashesRequest{Key: key, Hashes: []uint64{1, 2}, ResultChan: resultChan}), true)
+	assert.Equal(t, changed(&AddHashesRequest{Key: key, Hashes: []uint64{2}, ResultChan: resultChan}), false)
+	assert.Equal(t, changed(&DeleteExistingRequest{Key: key, ResultChan: resultChan}), true)
+	assert.Equal(t, changed(&DeleteExistingRequest{Key: key, ResultChan: resultChan}), false)
+}
+
+func TestDBKeys(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	keys := []string{"_GOTEST_KEYS:a", "_GOTEST_KEYS:b", "_GOTEST_KEYS:c"}
+	resultChan := make(chan Result)
+
+	clean := func() {
+		for _, key := range keys {
+			delRequest := DeleteRequest{
+				Key:        key,
+				ResultChan: resultChan,
+			}
+			testStore.Do(delRequest)
+			<-resultChan
+		}
+	}
+	clean()
+	defer clean()
+
+	for i, key := range keys {
+		for j := 0; j <= i; j++ {
+			testStore.Do(AddHashRequest{
+				Key:        key,
+				Hash:       GetRandHash(),
+				Size:       10,
+				ResultChan: resultChan,
+			})
+			<-resultChan
+		}
+	}
+
+	keysChan := make(chan KeysResult)
+	keysRequest := &KeysRequest{
+		Prefix:     "_GOTEST_KEYS:",
+		Limit:      2,
+		Details:    true,
+		ResultChan: keysChan,
+	}
+	testStore.Do(keysRequest)
+	keysResult := <-keysChan
+	assert.Equal(t, keysResult.Error, nil)
+	assert.Equal(t, keysResult.Keys, []KeyInfo{{keys[0], "kmv", 1, 10, true}, {keys[1], "kmv", 2, 10, true}})
+	assert.Equal(t, keysResult.Cursor, keys[1])
+
+	keysRequest = &KeysRequest{
+		Prefix:     "_GOTEST_KEYS:",
+		Cursor:     keysResult.Cursor,
+		Limit:      2,
+		ResultChan: keysChan,
+	}
+	testStore.Do(keysRequest)
+	keysResult = <-keysChan
+	assert.Equal(t, keysResult.Error, nil)
+	assert.Equal(t, keysResult.Keys, []KeyInfo{{Key: keys[2]}})
+	assert.Equal(t, keysResult.Cursor, "")
+}
+
+var testStore *Store
+
+func SetupDB() {
+	SetupDBWorkers(1)
+}
+
+func SetupDBWorkers(nWorkers int) {
+	SetupDBOptions(Options{Workers: nWorkers})
+}
+
+func SetupDBOptions(options Options) {
+	testStore = New(NewMemoryBackend(), options)
+}
+
+func CloseDB() {
+	testStore.Close()
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Reads a map of key prefixes to the size new KMin Value sets under that
+// prefix should be created with.  The file is a json object such as,
+//
+//	{ "pageviews:" : 4096, "pageviews:archive:" : 128 }
+//
+// When several prefixes match a key the longest one wins.
+func LoadKeySizes(filename string) (map[string]int, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]int)
+	err = json.Unmarshal(data, &sizes)
+	if err != nil {
+		return nil, err
+	}
+
+	for prefix, size := range sizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("Size for prefix '%s' must be greater than 0", prefix)
+		}
+	}
+	return sizes, nil
+}
+
+func keySize(keySizes map[string]int, defaultSize int, key string) int {
+	size := defaultSize
+	matched := -1
+	for prefix, prefixSize := range keySizes {
+		if len(prefix) > matched && strings.HasPrefix(key, prefix) {
+			size = prefixSize
+			matched = len(prefix)
+		}
+	}
+	return size
+}
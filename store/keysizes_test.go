@@ -0,0 +1,56 @@
+package store
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestKeySizes(t *testing.T) {
+	f, err := ioutil.TempFile("", "gocountme_sizes")
+	assert.Equal(t, err, nil)
+	defer os.Remove(f.Name())
+	f.WriteString(`{"hot:" : 4096, "hot:tail:" : 16}`)
+	f.Close()
+
+	sizes, err := LoadKeySizes(f.Name())
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, keySize(sizes, 1024, "hot:key"), 4096)
+	assert.Equal(t, keySize(sizes, 1024, "hot:tail:key"), 16)
+	assert.Equal(t, keySize(sizes, 1024, "cold:key"), 1024)
+}
+
+func TestAddHashSize(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	key := "_GOTEST_TESTADDHASHSIZE"
+	resultChan := make(chan Result)
+
+	clean := func() {
+		delRequest := DeleteRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		}
+		testStore.Do(delRequest)
+		<-resultChan
+	}
+	clean()
+	defer clean()
+
+	for i := 0; i < 20; i++ {
+		addHashRequest := AddHashRequest{
+			Key:        key,
+			Hash:       GetRandHash(),
+			Size:       5,
+			ResultChan: resultChan,
+		}
+		testStore.Do(addHashRequest)
+		result := <-resultChan
+		assert.Equal(t, result.Error, nil)
+		assert.T(t, result.Data.(*kminvalues.KMinValues).Len() <= 5)
+	}
+}
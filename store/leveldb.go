@@ -0,0 +1,90 @@
+//go:build cgo
+
+package store
+
+import (
+	"github.com/jmhodges/levigo"
+)
+
+func init() {
+	backends["leveldb"] = func(path string, options Options) (Backend, error) {
+		return OpenLevelDB(path, options.LevelDBCache)
+	}
+}
+
+// Stores sets in LevelDB through levigo, which needs cgo and the LevelDB C
+// library
+type LevelDBBackend struct {
+	db *levigo.DB
+	ro *levigo.ReadOptions
+	wo *levigo.WriteOptions
+}
+
+// Opens the LevelDB database at path, creating it if it doesn't exist, with
+// an LRU cache of cacheSize bytes
+func OpenLevelDB(path string, cacheSize int) (*LevelDBBackend, error) {
+	opts := levigo.NewOptions()
+	opts.SetCache(levigo.NewLRUCache(cacheSize))
+	opts.SetCreateIfMissing(true)
+	db, err := levigo.Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDBBackend{
+		db: db,
+		ro: levigo.NewReadOptions(),
+		wo: levigo.NewWriteOptions(),
+	}, nil
+}
+
+func (lb *LevelDBBackend) Get(key []byte) ([]byte, error) {
+	data, err := lb.db.Get(lb.ro, key)
+	if len(data) == 0 {
+		return nil, err
+	}
+	return data, err
+}
+
+func (lb *LevelDBBackend) Put(key, value []byte) error {
+	return lb.db.Put(lb.wo, key, value)
+}
+
+func (lb *LevelDBBackend) Delete(key []byte) error {
+	return lb.db.Delete(lb.wo, key)
+}
+
+// Iterates over a snapshot of the database taken when Iterate is called
+func (lb *LevelDBBackend) Iterate(start []byte, f func(key, value []byte) bool) error {
+	iterOptions := levigo.NewReadOptions()
+	iterOptions.SetFillCache(false)
+	defer iterOptions.Close()
+	it := lb.db.NewIterator(iterOptions)
+	defer it.Close()
+
+	for it.Seek(start); it.Valid(); it.Next() {
+		if !f(it.Key(), it.Value()) {
+			break
+		}
+	}
+	return it.GetError()
+}
+
+func (lb *LevelDBBackend) Write(batch *Batch) error {
+	wb := levigo.NewWriteBatch()
+	defer wb.Close()
+	for _, op := range batch.ops {
+		if op.delete {
+			wb.Delete(op.key)
+		} else {
+			wb.Put(op.key, op.value)
+		}
+	}
+	return lb.db.Write(lb.wo, wb)
+}
+
+func (lb *LevelDBBackend) Close() error {
+	lb.ro.Close()
+	lb.wo.Close()
+	lb.db.Close()
+	return nil
+}
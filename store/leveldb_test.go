@@ -0,0 +1,19 @@
+//go:build cgo
+
+package store
+
+import (
+	"github.com/bmizerany/assert"
+	"os"
+	"testing"
+)
+
+func TestLevelDBBackend(t *testing.T) {
+	dir, err := os.MkdirTemp("", "gocountme_leveldb")
+	assert.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	backend, err := OpenLevelDB(dir, 1024)
+	assert.Equal(t, err, nil)
+	testBackend(t, backend)
+}
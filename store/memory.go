@@ -0,0 +1,91 @@
+package store
+
+import (
+	"sort"
+	"sync"
+)
+
+// Keeps everything in a map.  Nothing is written to disk, so this is only
+// useful for tests and for sets that don't need to outlive the process.
+type MemoryBackend struct {
+	sync.RWMutex
+	data map[string][]byte
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{data: make(map[string][]byte)}
+}
+
+func (mb *MemoryBackend) Get(key []byte) ([]byte, error) {
+	mb.RLock()
+	defer mb.RUnlock()
+	return copyBytes(mb.data[string(key)]), nil
+}
+
+func (mb *MemoryBackend) Put(key, value []byte) error {
+	mb.Lock()
+	defer mb.Unlock()
+	mb.data[string(key)] = copyBytes(value)
+	return nil
+}
+
+func (mb *MemoryBackend) Delete(key []byte) error {
+	mb.Lock()
+	defer mb.Unlock()
+	delete(mb.data, string(key))
+	return nil
+}
+
+// Iterates over a copy of the keys taken when Iterate is called, so f may
+// change the backend while iterating
+func (mb *MemoryBackend) Iterate(start []byte, f func(key, value []byte) bool) error {
+	mb.RLock()
+	keys := make([]string, 0)
+	for key := range mb.data {
+		if key >= string(start) {
+			keys = append(keys, key)
+		}
+	}
+	mb.RUnlock()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, err := mb.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			continue
+		}
+		if !f([]byte(key), value) {
+			break
+		}
+	}
+	return nil
+}
+
+func (mb *MemoryBackend) Write(batch *Batch) error {
+	mb.Lock()
+	defer mb.Unlock()
+	for _, op := range batch.ops {
+		if op.delete {
+			delete(mb.data, string(op.key))
+		} else {
+			mb.data[string(op.key)] = copyBytes(op.value)
+		}
+	}
+	return nil
+}
+
+func (mb *MemoryBackend) Close() error {
+	return nil
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
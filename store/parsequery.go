@@ -0,0 +1,485 @@
+package store
+
+//
+//    in order to request:
+//
+//        Jaccard( key1 u key2, key8 n key3 )
+//
+//    we request:
+//
+//    {
+//        "method" : "jaccard",
+//        "set" : [
+//            {
+//                "method" : "union",
+//                "keys" : ["key1", "key2"],
+//            },
+//            {
+//                "method" : "intersection",
+//                "keys" : ["key8", "key3"],
+//            },
+//
+//        ]
+//    }
+//
+//    ============================================
+//
+//    in order to request:
+//
+//        Card( (key1 u key2 u key3) n key5)
+//
+//    we request:
+//
+//    {
+//        "method" : "cardinality",
+//        "set" : [
+//            {
+//                "method" : "intersection",
+//                "set" : [
+//                    {
+//                        "method" : "union",
+//                        "keys" : ["key1", "key2", "key3"]
+//                    },
+//                    {
+//                        "method" : "get",
+//                        "keys" : ["key5"]
+//                    },
+//                ]
+//            },
+//        ]
+//    }
+//////////////////////////////////////////////////////////////////////
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"regexp"
+	"strings"
+)
+
+var (
+	KeysAndSetError            = errors.New("Only one of keys, set or prefix can be specified in query")
+	CardinalitySingleTermError = errors.New("Method 'cardinality' can only take in one data source")
+	GetSingleTermError         = errors.New("Method 'get' can only take in one data source")
+	SetNeedsKMV                = errors.New("Set specified with float output")
+	InvalidMethod              = errors.New("Unrecognized method")
+	MethodSetSize              = errors.New("Method requires 2+ sets or keys")
+	MethodNoData               = errors.New("Method requires 1+ sets or keys")
+	ContainmentTwoTerms        = errors.New("Method 'containment' takes exactly two data sources")
+	PrefixNoKeys               = errors.New("No keys match prefix")
+	InvalidPrefixPattern       = errors.New("Prefix is not a valid glob pattern")
+	WeightedNeedsKeys          = errors.New("Methods 'sum' and 'mean' can only take in weighted keys")
+)
+
+type Element struct {
+	Method string    `json:"method"`
+	Set    []Element `json:"set,omitempty"`
+	Keys   []string  `json:"keys,omitempty"`
+	Prefix string    `json:"prefix,omitempty"`
+}
+
+type QueryResult struct {
+	Key      string            `json:"key"`
+	Kmv      kminvalues.Sketch `json:"set"`
+	Num      float64           `json:"result"`
+	Interval *Estimate         `json:"interval,omitempty"`
+	Multi    []*QueryResult    `json:"multi_result,omitempty"`
+}
+
+const DefaultConfidence = 0.95
+
+// An estimated number along with the interval that holds the true value with
+// the given confidence.  Exact is set when the sets involved were small enough
+// to hold every item, in which case the estimate is the true value.
+type Estimate struct {
+	Estimate   float64 `json:"estimate"`
+	Lower      float64 `json:"lower"`
+	Upper      float64 `json:"upper"`
+	Confidence float64 `json:"confidence"`
+	Exact      bool    `json:"exact"`
+}
+
+func SketchEstimate(sketch kminvalues.Sketch, confidence float64) *Estimate {
+	lower, upper := sketch.Bounds(kminvalues.StdDevs(confidence))
+	return &Estimate{sketch.Cardinality(), lower, upper, confidence, sketch.Exact()}
+}
+
+func ContainmentEstimate(confidence float64, a, b *kminvalues.ThetaSketch) *Estimate {
+	containment, lower, upper := kminvalues.ThetaContainmentBounds(kminvalues.StdDevs(confidence), a, b)
+	return &Estimate{containment, lower, upper, confidence, a.Exact() && b.Exact()}
+}
+
+func JaccardEstimate(confidence float64, sketches ...*kminvalues.ThetaSketch) *Estimate {
+	jaccard, lower, upper := kminvalues.ThetaJaccardBounds(kminvalues.StdDevs(confidence), sketches...)
+	exact := kminvalues.ThetaUnion(sketches...).Exact()
+	return &Estimate{jaccard, lower, upper, confidence, exact}
+}
+
+func (s *Store) Query(query_raw []byte) (*QueryResult, error) {
+	return s.QueryConfidence(query_raw, DefaultConfidence)
+}
+
+// Parses and runs a query.  Numeric results come with an interval at the
+// given confidence.
+func (s *Store) QueryConfidence(query_raw []byte, confidence float64) (*QueryResult, error) {
+	query := Element{}
+	err := json.Unmarshal(query_raw, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.parseQuery(&query, confidence)
+}
+
+func (s *Store) parseQuery(e *Element, confidence float64) (*QueryResult, error) {
+	nSources := 0
+	for _, source := range []bool{len(e.Keys) != 0, len(e.Set) != 0, e.Prefix != ""} {
+		if source {
+			nSources++
+		}
+	}
+	if nSources > 1 {
+		return nil, KeysAndSetError
+	}
+
+	if e.Method == "sum" || e.Method == "mean" {
+		return s.parseWeightedQuery(e, confidence)
+	}
+
+	var data []kminvalues.Sketch
+	var keys []string
+	var err error
+
+	if e.Prefix != "" {
+		keys, err = s.prefixKeys(e.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 {
+			return nil, PrefixNoKeys
+		}
+		data, err = s.GetSets(keys)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(e.Keys) != 0 {
+		data, err = s.GetSets(e.Keys)
+		if err != nil {
+			return nil, err
+		}
+		keys = e.Keys
+	} else if len(e.Set) != 0 {
+		data = make([]kminvalues.Sketch, len(e.Set))
+		keys = make([]string, len(e.Set))
+		for i := 0; i < len(e.Set); i++ {
+			tmp, err := s.parseQuery(&e.Set[i], confidence)
+			if err != nil {
+				return nil, err
+			} else if tmp.Kmv == nil {
+				return nil, SetNeedsKMV
+			}
+			data[i] = tmp.Kmv
+			keys[i] = tmp.Key
+		}
+	}
+
+	// Everything but cardinalities and unions works on theta sketches, which
+	// keep their threshold so intersections and differences can be nested
+	thetas, thetaErr := kminvalues.AsThetaSketches(data...)
+
+	if e.Method == "cardinality" {
+		if len(data) != 1 {
+			return nil, CardinalitySingleTermError
+		}
+		interval := SketchEstimate(data[0], confidence)
+		return &QueryResult{
+			Key:      fmt.Sprintf("||%s||", keys[0]),
+			Num:      interval.Estimate,
+			Interval: interval,
+		}, nil
+	} else if e.Method == "get" {
+		if len(data) != 1 {
+			return nil, CardinalitySingleTermError
+		}
+		return &QueryResult{
+			Key: keys[0],
+			Kmv: data[0],
+		}, nil
+	} else if e.Method == "union" {
+		if len(data) < 1 {
+			return nil, MethodNoData
+		}
+		tmp, err := kminvalues.UnionSketches(data...)
+		if err != nil {
+			return nil, err
+		}
+		return &QueryResult{
+			Key: strings.Join(keys, " u "),
+			Kmv: tmp,
+		}, nil
+	} else if e.Method == "intersection" {
+		if len(data) < 2 {
+			return nil, MethodSetSize
+		} else if thetaErr != nil {
+			return nil, thetaErr
+		}
+		tmp := kminvalues.ThetaIntersection(thetas...)
+		return &QueryResult{
+			Key: strings.Join(keys, " n "),
+			Kmv: tmp,
+		}, nil
+	} else if e.Method == "difference" {
+		if len(data) < 2 {
+			return nil, MethodSetSize
+		} else if thetaErr != nil {
+			return nil, thetaErr
+		}
+		tmp := kminvalues.ThetaAnotB(thetas...)
+		return &QueryResult{
+			Key: strings.Join(keys, " \\ "),
+			Kmv: tmp,
+		}, nil
+	} else if e.Method == "jaccard" {
+		if len(data) < 2 {
+			return nil, MethodSetSize
+		} else if thetaErr != nil {
+			return nil, thetaErr
+		}
+		interval := JaccardEstimate(confidence, thetas...)
+		return &QueryResult{
+			Key:      fmt.Sprintf("Jaccard(%s)", strings.Join(keys, ", ")),
+			Num:      interval.Estimate,
+			Interval: interval,
+		}, nil
+	} else if e.Method == "containment" {
+		if len(data) != 2 {
+			return nil, ContainmentTwoTerms
+		} else if thetaErr != nil {
+			return nil, thetaErr
+		}
+		interval := ContainmentEstimate(confidence, thetas[0], thetas[1])
+		return &QueryResult{
+			Key:      fmt.Sprintf("Containment(%s, %s)", keys[0], keys[1]),
+			Num:      interval.Estimate,
+			Interval: interval,
+		}, nil
+	} else if e.Method == "cardinality_intersection" {
+		if len(data) < 2 {
+			return nil, MethodSetSize
+		} else if thetaErr != nil {
+			return nil, thetaErr
+		}
+		interval := SketchEstimate(kminvalues.ThetaIntersection(thetas...), confidence)
+		return &QueryResult{
+			Key:      fmt.Sprintf("||%s||", strings.Join(keys, " n ")),
+			Num:      interval.Estimate,
+			Interval: interval,
+		}, nil
+	} else if e.Method == "cardinality_union" {
+		if len(data) < 1 {
+			return nil, MethodNoData
+		}
+		tmp, err := kminvalues.UnionSketches(data...)
+		if err != nil {
+			return nil, err
+		}
+		interval := SketchEstimate(tmp, confidence)
+		return &QueryResult{
+			Key:      fmt.Sprintf("||%s||", strings.Join(keys, " u ")),
+			Num:      interval.Estimate,
+			Interval: interval,
+		}, nil
+	} else if e.Method == "correlation" {
+		if len(data) < 2 {
+			return nil, MethodSetSize
+		} else if thetaErr != nil {
+			return nil, thetaErr
+		}
+
+		N := len(thetas)
+		correlation := make([]*QueryResult, 0, N*(N-1)/2)
+		for i, r1 := range thetas[:N-1] {
+			for j, r2 := range thetas[i+1:] {
+				interval := JaccardEstimate(confidence, r1, r2)
+				correlation = append(correlation, &QueryResult{
+					Key:      fmt.Sprintf("Jaccard(%s, %s)", keys[i], keys[j+i+1]),
+					Num:      interval.Estimate,
+					Interval: interval,
+				})
+			}
+		}
+		return &QueryResult{
+			Key:   fmt.Sprintf("Corr(%s)", strings.Join(keys, ", ")),
+			Multi: correlation,
+		}, nil
+	}
+	return nil, InvalidMethod
+}
+
+// Answers the methods that work on weighted sets.  The weighted sets of all
+// the keys are combined so items in more than one of them are only counted
+// once.
+func (s *Store) parseWeightedQuery(e *Element, confidence float64) (*QueryResult, error) {
+	if len(e.Keys) == 0 || len(e.Set) != 0 || e.Prefix != "" {
+		return nil, WeightedNeedsKeys
+	}
+	data, err := s.GetWeightedSets(e.Keys)
+	if err != nil {
+		return nil, err
+	}
+	union := data[0].Union(data[1:]...)
+	numStdDev := kminvalues.StdDevs(confidence)
+
+	if e.Method == "sum" {
+		sum, lower, upper := union.SumBounds(numStdDev)
+		return &QueryResult{
+			Key:      fmt.Sprintf("Sum(%s)", strings.Join(e.Keys, " u ")),
+			Num:      sum,
+			Interval: &Estimate{sum, lower, upper, confidence, union.Exact()},
+		}, nil
+	}
+	mean, lower, upper := union.MeanBounds(numStdDev)
+	return &QueryResult{
+		Key:      fmt.Sprintf("Mean(%s)", strings.Join(e.Keys, " u ")),
+		Num:      mean,
+		Interval: &Estimate{mean, lower, upper, confidence, union.Exact()},
+	}, nil
+}
+
+// Fetches the set for every key.  The sets are returned in the same order as
+// the keys and keys that don't exist give empty sets.
+func (s *Store) GetSets(keys []string) ([]kminvalues.Sketch, error) {
+	data, _, err := s.getSets(keys)
+	return data, err
+}
+
+// Like GetSets but also says which of the keys exist
+func (s *Store) getSets(keys []string) ([]kminvalues.Sketch, []bool, error) {
+	resultChan := make(chan Result, len(keys))
+	defer close(resultChan)
+	idxs := make(map[string][]int, len(keys))
+	for i, key := range keys {
+		idxs[key] = append(idxs[key], i)
+		getRequest := GetRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		}
+		s.Do(getRequest)
+	}
+
+	data := make([]kminvalues.Sketch, len(keys))
+	found := make([]bool, len(keys))
+	var err error
+	for range keys {
+		result := <-resultChan
+		if result.Error != nil && result.Error != KeyNotFound {
+			err = result.Error
+			continue
+		}
+		i := idxs[result.Key][0]
+		idxs[result.Key] = idxs[result.Key][1:]
+		data[i] = result.Data
+		found[i] = result.Error == nil
+	}
+	return data, found, err
+}
+
+// Returns the keys a query's prefix stands for.  A prefix with glob characters,
+// such as `users:2026-10-*`, has to match the whole key, otherwise every key
+// starting with the prefix is returned.
+func (s *Store) prefixKeys(prefix string) ([]string, error) {
+	i := strings.IndexAny(prefix, `*?[\`)
+	if i < 0 {
+		return s.ListKeys(prefix)
+	}
+	pattern, err := globRegexp(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.ListKeys(prefix[:i])
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(candidates))
+	for _, key := range candidates {
+		if pattern.MatchString(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Compiles a glob into a regexp matching whole keys.  Unlike path.Match, `*`
+// and `?` also match `/` since keys are often paths, eg: `pageviews:/home`.
+// `[...]` matches one character of a class, negated with `[!...]` or
+// `[^...]`, and `\` makes the next character literal.
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	re.WriteString(`(?s)^`)
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*':
+			re.WriteString(`.*`)
+		case '?':
+			re.WriteString(`.`)
+		case '\\':
+			i += 1
+			if i == len(glob) {
+				return nil, InvalidPrefixPattern
+			}
+			re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return nil, InvalidPrefixPattern
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	re.WriteString(`$`)
+
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return nil, InvalidPrefixPattern
+	}
+	return compiled, nil
+}
+
+// Returns every key that starts with prefix
+func (s *Store) ListKeys(prefix string) ([]string, error) {
+	resultChan := make(chan KeysResult)
+	defer close(resultChan)
+
+	keys := make([]string, 0)
+	cursor := ""
+	for {
+		keysRequest := &KeysRequest{
+			Prefix:     prefix,
+			Cursor:     cursor,
+			Limit:      1000,
+			ResultChan: resultChan,
+		}
+		s.Do(keysRequest)
+		result := <-resultChan
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		for _, info := range result.Keys {
+			keys = append(keys, info.Key)
+		}
+		if result.Cursor == "" {
+			return keys, nil
+		}
+		cursor = result.Cursor
+	}
+}
@@ -0,0 +1,327 @@
+package store
+
+import (
+	"fmt"
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"log"
+	"math"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	SetupDB()
+	query := `
+{
+    "method" : "jaccard",
+    "set" : [
+        {
+            "method" : "union",
+            "keys" : ["test1", "test2"]
+        }, 
+        {
+            "method" : "get",
+            "keys" : ["test3"]
+        }
+    ]
+}
+`
+	log.Println(testStore.Query([]byte(query)))
+	CloseDB()
+}
+
+func TestParseQueryIntersection(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	resultChan := make(chan Result)
+	sets := map[string][]uint64{
+		"_GOTEST_QUERY1": {1, 2, 3, 4},
+		"_GOTEST_QUERY2": {3, 4, 5, 6},
+	}
+	for key, hashes := range sets {
+		kmv := kminvalues.NewKMinValues(10)
+		for _, hash := range hashes {
+			kmv.AddHash(hash)
+		}
+		testStore.Do(SetRequest{
+			Key:        key,
+			Kmv:        kmv,
+			ResultChan: resultChan,
+		})
+		<-resultChan
+		defer func(key string) {
+			testStore.Do(DeleteRequest{
+				Key:        key,
+				ResultChan: resultChan,
+			})
+			<-resultChan
+		}(key)
+	}
+
+	for method, expected := range map[string]float64{"intersection": 2, "difference": 2, "union": 6} {
+		query := fmt.Sprintf(`
+{
+    "method" : "cardinality",
+    "set" : [
+        {
+            "method" : "%s",
+            "keys" : ["_GOTEST_QUERY1", "_GOTEST_QUERY2"]
+        }
+    ]
+}
+`, method)
+		result, err := testStore.Query([]byte(query))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, result.Num, expected)
+	}
+
+	result, err := testStore.Query([]byte(`{"method" : "containment", "keys" : ["_GOTEST_QUERY1", "_GOTEST_QUERY2"]}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *result.Interval, Estimate{0.5, 0.5, 0.5, DefaultConfidence, true})
+	_, err = testStore.Query([]byte(`{"method" : "containment", "keys" : ["_GOTEST_QUERY1"]}`))
+	assert.Equal(t, err, ContainmentTwoTerms)
+}
+
+func TestParseQueryPrefix(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	resultChan := make(chan Result)
+	sets := map[string][]uint64{
+		"_GOTEST_PREFIX:1":          {1, 2},
+		"_GOTEST_PREFIX:2":          {2, 3},
+		"_GOTEST_PREFIX:3":          {3, 4, 5},
+		"_GOTEST_PREFIXX":           {6},
+		"_GOTEST_PAGES:/home":       {7, 8},
+		"_GOTEST_PAGES:/home/about": {8, 9},
+	}
+	for key, hashes := range sets {
+		kmv := kminvalues.NewKMinValues(10)
+		for _, hash := range hashes {
+			kmv.AddHash(hash)
+		}
+		testStore.Do(SetRequest{
+			Key:        key,
+			Kmv:        kmv,
+			ResultChan: resultChan,
+		})
+		<-resultChan
+		defer func(key string) {
+			testStore.Do(DeleteRequest{
+				Key:        key,
+				ResultChan: resultChan,
+			})
+			<-resultChan
+		}(key)
+	}
+
+	result, err := testStore.Query([]byte(`{"method" : "cardinality_union", "prefix" : "_GOTEST_PREFIX:"}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Num, 5.0)
+
+	result, err = testStore.Query([]byte(`{"method" : "correlation", "prefix" : "_GOTEST_PREFIX:"}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(result.Multi), 3)
+	assert.Equal(t, result.Multi[0].Key, "Jaccard(_GOTEST_PREFIX:1, _GOTEST_PREFIX:2)")
+	assert.Equal(t, result.Multi[0].Num, 1.0/3.0)
+	assert.Equal(t, result.Multi[0].Interval.Exact, true)
+
+	result, err = testStore.Query([]byte(`{"method" : "cardinality_union", "prefix" : "_GOTEST_PREFIX*"}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Num, 6.0)
+
+	result, err = testStore.Query([]byte(`{"method" : "cardinality_union", "prefix" : "_GOTEST_PREFIX:[12]"}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Num, 3.0)
+
+	// globs match across the slashes of path like keys
+	result, err = testStore.Query([]byte(`{"method" : "cardinality_union", "prefix" : "_GOTEST_PAGES:*"}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Num, 3.0)
+
+	result, err = testStore.Query([]byte(`{"method" : "cardinality_union", "prefix" : "_GOTEST_PAGES:/home/*"}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Num, 2.0)
+
+	_, err = testStore.Query([]byte(`{"method" : "union", "prefix" : "_GOTEST_NOPREFIX:"}`))
+	assert.Equal(t, err, PrefixNoKeys)
+
+	_, err = testStore.Query([]byte(`{"method" : "union", "prefix" : "_GOTEST_PREFIX:*:x"}`))
+	assert.Equal(t, err, PrefixNoKeys)
+
+	_, err = testStore.Query([]byte(`{"method" : "union", "prefix" : "_GOTEST_PREFIX:["}`))
+	assert.Equal(t, err, InvalidPrefixPattern)
+
+	_, err = testStore.Query([]byte(`{"method" : "union", "prefix" : "_GOTEST_PREFIX:", "keys" : ["a"]}`))
+	assert.Equal(t, err, KeysAndSetError)
+}
+
+func TestParseQueryWeighted(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	resultChan := make(chan Result)
+	weights := map[string]map[uint64]float64{
+		"_GOTEST_WEIGHTED1": {1: 10, 2: 20},
+		"_GOTEST_WEIGHTED2": {2: 5, 3: 30},
+	}
+	for key, values := range weights {
+		testStore.Do(DeleteRequest{
+			Key:        WeightedPrefix + key,
+			ResultChan: resultChan,
+		})
+		<-resultChan
+		for hash, value := range values {
+			testStore.Do(WeightedAddRequest{
+				Key:        key,
+				Hash:       hash,
+				Value:      value,
+				Size:       10,
+				ResultChan: resultChan,
+			})
+			result := <-resultChan
+			assert.Equal(t, result.Error, nil)
+		}
+	}
+
+	result, err := testStore.Query([]byte(`{"method" : "sum", "keys" : ["_GOTEST_WEIGHTED1", "_GOTEST_WEIGHTED2"]}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Num, 65.0)
+
+	result, err = testStore.Query([]byte(`{"method" : "mean", "keys" : ["_GOTEST_WEIGHTED1"]}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Num, 15.0)
+
+	_, err = testStore.Query([]byte(`{"method" : "sum", "prefix" : "_GOTEST_WEIGHTED"}`))
+	assert.Equal(t, err, WeightedNeedsKeys)
+
+	testStore.Do(WeightedAddRequest{
+		Key:        "_GOTEST_WEIGHTEDSMALL",
+		Hash:       1,
+		Value:      1,
+		Size:       2,
+		ResultChan: resultChan,
+	})
+	assert.Equal(t, (<-resultChan).Error, WeightedSizeTooSmall)
+
+	for _, weight := range []float64{0, -1, math.Inf(1), math.NaN()} {
+		testStore.Do(WeightedAddRequest{
+			Key:        "_GOTEST_WEIGHTED1",
+			Hash:       1,
+			Value:      weight,
+			ResultChan: resultChan,
+		})
+		assert.Equal(t, (<-resultChan).Error, InvalidWeight)
+	}
+}
+
+func TestParseQueryHyperLogLog(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	resultChan := make(chan Result)
+	keys := []string{"_GOTEST_HLL1", "_GOTEST_HLL2", "_GOTEST_HLL_KMV"}
+	for _, key := range keys {
+		testStore.Do(DeleteRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		})
+		<-resultChan
+	}
+	for i := 0; i < 1000; i++ {
+		for j, key := range keys {
+			sketchType := kminvalues.TypeHLL
+			if j == 2 {
+				sketchType = kminvalues.TypeKMV
+			}
+			testStore.Do(AddHashRequest{
+				Key:        key,
+				Hash:       Hashify([]byte(fmt.Sprintf("%d", i+500*j))),
+				Size:       1024,
+				Type:       sketchType,
+				ResultChan: resultChan,
+			})
+			result := <-resultChan
+			assert.Equal(t, result.Error, nil)
+		}
+	}
+
+	result, err := testStore.Query([]byte(`{"method" : "cardinality_union", "keys" : ["_GOTEST_HLL1", "_GOTEST_HLL2", "_GOTEST_MISSING"]}`))
+	assert.Equal(t, err, nil)
+	if result.Num < 1400 || result.Num > 1600 {
+		t.Errorf("HyperLogLog union cardinality too far off: %f instead of 1500", result.Num)
+	}
+
+	_, err = testStore.Query([]byte(`{"method" : "jaccard", "keys" : ["_GOTEST_HLL1", "_GOTEST_HLL2"]}`))
+	assert.Equal(t, err, kminvalues.UnsupportedSketchType)
+	_, err = testStore.Query([]byte(`{"method" : "union", "keys" : ["_GOTEST_HLL1", "_GOTEST_HLL_KMV"]}`))
+	assert.Equal(t, err, kminvalues.MixedSketchTypes)
+}
+
+func TestParseQueryNested(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	resultChan := make(chan Result)
+	ranges := map[string][2]int{
+		"_GOTEST_NESTED1": {0, 20000},
+		"_GOTEST_NESTED2": {15000, 40000},
+		"_GOTEST_NESTED3": {18000, 30000},
+	}
+	for key, r := range ranges {
+		kmv := kminvalues.NewKMinValues(1024)
+		for i := r[0]; i < r[1]; i++ {
+			kmv.AddHash(Hashify([]byte(fmt.Sprintf("%d", i))))
+		}
+		testStore.Do(SetRequest{
+			Key:        key,
+			Kmv:        kmv,
+			ResultChan: resultChan,
+		})
+		<-resultChan
+		defer func(key string) {
+			testStore.Do(DeleteRequest{
+				Key:        key,
+				ResultChan: resultChan,
+			})
+			<-resultChan
+		}(key)
+	}
+
+	// ((1 n 2) \ 3) holds 15000 through 17999
+	query := `
+{
+    "method" : "cardinality",
+    "set" : [
+        {
+            "method" : "difference",
+            "set" : [
+                {
+                    "method" : "intersection",
+                    "keys" : ["_GOTEST_NESTED1", "_GOTEST_NESTED2"]
+                },
+                {
+                    "method" : "get",
+                    "keys" : ["_GOTEST_NESTED3"]
+                }
+            ]
+        }
+    ]
+}
+`
+	result, err := testStore.QueryConfidence([]byte(query), 0.99)
+	assert.Equal(t, err, nil)
+	if math.Abs(result.Num-3000)/3000 > 0.5 {
+		t.Errorf("Nested query too far off: %f instead of 3000", result.Num)
+	}
+	interval := result.Interval
+	assert.Equal(t, interval.Confidence, 0.99)
+	assert.Equal(t, interval.Estimate, result.Num)
+	if 3000 < interval.Lower || 3000 > interval.Upper {
+		t.Errorf("Interval [%f, %f] doesn't contain 3000", interval.Lower, interval.Upper)
+	}
+
+	narrow, _ := testStore.QueryConfidence([]byte(query), 0.5)
+	assert.T(t, narrow.Interval.Upper-narrow.Interval.Lower < interval.Upper-interval.Lower)
+}
@@ -0,0 +1,321 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// Expiry times are stored as big endian unix timestamps under this prefix
+// followed by the key they belong to
+const expirePrefix = internalPrefix + "expire:"
+
+type ExpireRequest struct {
+	Key        string
+	TTL        time.Duration
+	ResultChan chan Result
+}
+
+// Lists up to Limit keys whose expiry time is before Now
+type ExpiredKeysRequest struct {
+	Now        time.Time
+	Limit      int
+	ResultChan chan KeysResult
+	result     KeysResult
+}
+
+// Unions the Sources sets into the Into set and deletes the sources
+type MergeRequest struct {
+	Sources    []string
+	Into       string
+	ResultChan chan Result
+}
+
+func (er ExpireRequest) WriteResult(result Result) {
+	result.Key = er.Key
+	er.ResultChan <- result
+}
+func (ekr *ExpiredKeysRequest) WriteResult(result Result) {
+	ekr.result.Error = result.Error
+	ekr.ResultChan <- ekr.result
+}
+func (mr MergeRequest) WriteResult(result Result) {
+	result.Key = mr.Into
+	mr.ResultChan <- result
+}
+
+// Sets the key to expire TTL from now.  A TTL of 0 means the key is kept
+// forever.
+func (er ExpireRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if er.Key == "" {
+		return nil, NoKeySpecified
+	}
+	defer s.lockKeys(er.Key)()
+
+	sketch, err := s.getSketch(er.Key)
+	if err != nil {
+		return nil, err
+	}
+	if sketch == nil {
+		return nil, KeyNotFound
+	}
+
+	if er.TTL <= 0 {
+		return sketch, s.db.Delete(expireKey(er.Key))
+	}
+	expireAt := time.Now().Add(er.TTL).Unix()
+	return sketch, s.db.Put(expireKey(er.Key), expireBytes(expireAt))
+}
+
+func (ekr *ExpiredKeysRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	now := ekr.Now.Unix()
+	ekr.result.Keys = make([]KeyInfo, 0)
+	err := s.db.Iterate([]byte(expirePrefix), func(keyBytes, value []byte) bool {
+		key := string(keyBytes)
+		if !strings.HasPrefix(key, expirePrefix) {
+			return false
+		}
+		if len(value) == 8 && int64(binary.BigEndian.Uint64(value)) <= now {
+			ekr.result.Keys = append(ekr.result.Keys, KeyInfo{Key: key[len(expirePrefix):]})
+		}
+		return len(ekr.result.Keys) < ekr.Limit
+	})
+	return nil, err
+}
+
+func (mr MergeRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if mr.Into == "" {
+		return nil, NoKeySpecified
+	}
+	defer s.lockKeys(append(mr.Sources, mr.Into)...)()
+
+	sketches := make([]kminvalues.Sketch, 0, len(mr.Sources)+1)
+	for _, key := range append(mr.Sources, mr.Into) {
+		sketch, err := s.getSketch(key)
+		if err != nil {
+			return nil, err
+		}
+		if sketch != nil {
+			sketches = append(sketches, sketch)
+		}
+	}
+	if len(sketches) == 0 {
+		return nil, KeyNotFound
+	}
+	merged, err := kminvalues.UnionSketches(sketches...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The merged set and the deletes go in one batch so that the sources
+	// aren't lost if we fail half way through.  Cached copies are dropped
+	// first so a cache flush can't overwrite the merged set.
+	if s.cache != nil {
+		for _, key := range append(mr.Sources, mr.Into) {
+			s.cache.Remove(key)
+		}
+	}
+	wb := &Batch{}
+	for _, key := range mr.Sources {
+		wb.Delete([]byte(key))
+		wb.Delete(expireKey(key))
+		wb.Delete(bucketIndexKey(key))
+	}
+	wb.Put([]byte(mr.Into), merged.Bytes())
+	indexBucket(wb, mr.Into)
+	err = s.db.Write(wb)
+	if err != nil {
+		if s.cache != nil {
+			// Keep whatever was only in the cache around
+			s.putSketch(mr.Into, merged)
+		}
+		return nil, err
+	}
+
+	data, err := s.db.Get(expireKey(mr.Into))
+	if err == nil && len(data) == 0 {
+		err = s.setExpire(mr.Into, 0)
+	}
+	return merged, err
+}
+
+func expireKey(key string) []byte {
+	return []byte(expirePrefix + key)
+}
+
+func expireBytes(expireAt int64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(expireAt))
+	return data
+}
+
+// Returns the unix time a newly created key should expire at, or 0 if it
+// should be kept forever.  When ttl is 0 the retention rules for the key are
+// used.  Time bucket keys count their TTL from the end of the bucket instead
+// of from now.
+func (s *Store) expireTime(key string, ttl time.Duration, now time.Time) int64 {
+	if ttl <= 0 {
+		ttl = retentionFor(s.options.RetentionRules, key)
+	}
+	if ttl <= 0 {
+		return 0
+	}
+
+	from := now.Unix()
+	if _, bucket, start, ok := parseBucketKey(key); ok {
+		from = start + BucketSizes[bucket]
+	}
+	return from + int64(ttl/time.Second)
+}
+
+// Adds what is kept next to a newly created key to the batch: its expiry time
+// and, for time buckets, its entry in the bucket index
+func (s *Store) initKey(wb *Batch, key string, ttl time.Duration, now time.Time) {
+	if expireAt := s.expireTime(key, ttl, now); expireAt != 0 {
+		wb.Put(expireKey(key), expireBytes(expireAt))
+	}
+	indexBucket(wb, key)
+}
+
+// Writes what initKey adds for a newly created key
+func (s *Store) setExpire(key string, ttl time.Duration) error {
+	wb := &Batch{}
+	s.initKey(wb, key, ttl, time.Now())
+	if wb.Len() == 0 {
+		return nil
+	}
+	return s.db.Write(wb)
+}
+
+// Reads a map of key prefixes to how long keys under that prefix are kept.
+// The file is a json object such as,
+//
+//	{ "sessions:" : "24h", "pageviews@minute:" : "168h" }
+//
+// When several prefixes match a key the longest one wins.
+func LoadRetentionRules(filename string) (map[string]time.Duration, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	rules_raw := make(map[string]string)
+	err = json.Unmarshal(data, &rules_raw)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string]time.Duration, len(rules_raw))
+	for prefix, ttl_raw := range rules_raw {
+		ttl, err := time.ParseDuration(ttl_raw)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("Retention for prefix '%s' must be a positive duration", prefix)
+		}
+		rules[prefix] = ttl
+	}
+	return rules, nil
+}
+
+func retentionFor(rules map[string]time.Duration, key string) time.Duration {
+	var ttl time.Duration
+	matched := -1
+	for prefix, prefixTTL := range rules {
+		if len(prefix) > matched && strings.HasPrefix(key, prefix) {
+			ttl = prefixTTL
+			matched = len(prefix)
+		}
+	}
+	return ttl
+}
+
+// What a call to Compact did
+type CompactStats struct {
+	// Number of expired keys that were deleted
+	Expired int
+	// Number of time buckets that were merged into coarser ones
+	Merged int
+	// Number of coarser buckets they were merged into
+	Into int
+}
+
+// Deletes every expired key and merges time buckets older than
+// Options.RollupMinutes and Options.RollupHours into hour and day buckets
+// respectively.  This runs every Options.CompactInterval on its own, with the
+// stats going to Options.OnCompact.
+func (s *Store) Compact(now time.Time) (CompactStats, error) {
+	var stats CompactStats
+	resultChan := make(chan Result)
+	defer close(resultChan)
+	keysChan := make(chan KeysResult)
+	defer close(keysChan)
+
+	for {
+		expiredKeysRequest := &ExpiredKeysRequest{
+			Now:        now,
+			Limit:      1000,
+			ResultChan: keysChan,
+		}
+		s.Do(expiredKeysRequest)
+		expired := <-keysChan
+		if expired.Error != nil {
+			return stats, expired.Error
+		}
+		for _, info := range expired.Keys {
+			s.Do(DeleteRequest{
+				Key:        info.Key,
+				ResultChan: resultChan,
+			})
+			if result := <-resultChan; result.Error != nil {
+				return stats, result.Error
+			}
+			stats.Expired++
+		}
+		if len(expired.Keys) < expiredKeysRequest.Limit {
+			break
+		}
+	}
+
+	if s.options.RollupMinutes <= 0 && s.options.RollupHours <= 0 {
+		return stats, nil
+	}
+	keys, err := s.indexedBuckets()
+	if err != nil {
+		return stats, err
+	}
+
+	merges := make(map[string][]string)
+	for _, key := range keys {
+		name, bucket, start, ok := parseBucketKey(key)
+		if !ok {
+			continue
+		}
+		end := time.Unix(start+BucketSizes[bucket], 0)
+		var into string
+		if bucket == "minute" && s.options.RollupMinutes > 0 && now.Sub(end) >= s.options.RollupMinutes {
+			into, _ = BucketKey(name, "hour", start)
+		} else if bucket == "hour" && s.options.RollupHours > 0 && now.Sub(end) >= s.options.RollupHours {
+			into, _ = BucketKey(name, "day", start)
+		} else {
+			continue
+		}
+		merges[into] = append(merges[into], key)
+	}
+
+	for into, sources := range merges {
+		s.Do(MergeRequest{
+			Sources:    sources,
+			Into:       into,
+			ResultChan: resultChan,
+		})
+		if result := <-resultChan; result.Error != nil {
+			return stats, result.Error
+		}
+		stats.Merged += len(sources)
+		stats.Into++
+	}
+	return stats, nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"testing"
+	"time"
+)
+
+func TestExpireTime(t *testing.T) {
+	s := &Store{options: Options{RetentionRules: map[string]time.Duration{"sessions:": time.Hour}}}
+
+	now := time.Unix(10000, 0)
+	assert.Equal(t, s.expireTime("users:1", 0, now), int64(0))
+	assert.Equal(t, s.expireTime("users:1", time.Minute, now), int64(10060))
+	assert.Equal(t, s.expireTime("sessions:1", 0, now), int64(13600))
+
+	bucket, _ := BucketKey("sessions:1", "minute", 120)
+	assert.Equal(t, s.expireTime(bucket, 0, now), int64(180+3600))
+}
+
+func TestCompact(t *testing.T) {
+	SetupDBOptions(Options{Workers: 1, RollupMinutes: time.Hour})
+	defer CloseDB()
+
+	resultChan := make(chan Result)
+	get := func(key string) Result {
+		testStore.Do(GetRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		})
+		return <-resultChan
+	}
+
+	key := "_GOTEST_COMPACT"
+	testStore.Do(AddHashRequest{
+		Key:        key,
+		Hash:       1,
+		TTL:        time.Minute,
+		ResultChan: resultChan,
+	})
+	<-resultChan
+
+	_, err := testStore.Compact(time.Now())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, get(key).Error, nil)
+
+	_, err = testStore.Compact(time.Now().Add(2 * time.Minute))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, get(key).Error, KeyNotFound)
+
+	minutes := make([]string, 3)
+	for i := range minutes {
+		minutes[i], _ = BucketKey(key, "minute", int64(60*i))
+		testStore.Do(AddHashRequest{
+			Key:        minutes[i],
+			Hash:       uint64(i + 1),
+			ResultChan: resultChan,
+		})
+		<-resultChan
+	}
+	hour, _ := BucketKey(key, "hour", 0)
+	defer func() {
+		testStore.Do(DeleteRequest{
+			Key:        hour,
+			ResultChan: resultChan,
+		})
+		<-resultChan
+	}()
+
+	_, err = testStore.Compact(time.Unix(60*60, 0))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, get(minutes[0]).Error, nil)
+
+	stats, err := testStore.Compact(time.Unix(60*60*3, 0))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, stats, CompactStats{Merged: 3, Into: 1})
+	for _, minute := range minutes {
+		assert.Equal(t, get(minute).Error, KeyNotFound)
+	}
+	result := get(hour)
+	assert.Equal(t, result.Error, nil)
+	assert.Equal(t, result.Data.(*kminvalues.KMinValues).Len(), 3)
+
+	// minute range queries fall back to the hour bucket they were merged into
+	union, err := testStore.TimeRange(key, "minute", 0, 90)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, union.Cardinality(), 3.0)
+
+	// and only the hour bucket is left in the index
+	buckets, err := testStore.indexedBuckets()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, buckets, []string{hour})
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"github.com/mynameisfiber/gocountme/kminvalues"
+)
+
+// Sliding window sets are stored under this prefix followed by their key so
+// that they never get mixed up with regular sets
+const SlidingPrefix = internalPrefix + "sliding:"
+
+type SlidingAddRequest struct {
+	Key        string
+	Hash       uint64
+	Seen       int64
+	Size       int
+	Window     int64
+	ResultChan chan Result
+}
+
+// Returns the KMinValues of everything the sliding window set Key has seen
+// since Since
+type SlidingGetRequest struct {
+	Key        string
+	Since      int64
+	ResultChan chan Result
+}
+
+func (sar SlidingAddRequest) WriteResult(result Result) {
+	result.Key = sar.Key
+	sar.ResultChan <- result
+}
+func (sgr SlidingGetRequest) WriteResult(result Result) {
+	result.Key = sgr.Key
+	sgr.ResultChan <- result
+}
+
+func (sar SlidingAddRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if sar.Key == "" {
+		return nil, NoKeySpecified
+	}
+	keyBytes := []byte(SlidingPrefix + sar.Key)
+	defer s.lockKeys(string(keyBytes))()
+
+	skmv, err := getSlidingKMinValues(s.db, keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if skmv == nil {
+		size := sar.Size
+		if size <= 0 {
+			size = s.KeySize(sar.Key)
+		}
+		skmv = kminvalues.NewSlidingKMinValues(size, sar.Window)
+	}
+	skmv.AddHash(sar.Hash, sar.Seen)
+
+	err = s.db.Put(keyBytes, skmv.Bytes())
+	return nil, err
+}
+
+func (sgr SlidingGetRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if sgr.Key == "" {
+		return nil, NoKeySpecified
+	}
+	keyBytes := []byte(SlidingPrefix + sgr.Key)
+	defer s.lockKeys(string(keyBytes))()
+
+	skmv, err := getSlidingKMinValues(s.db, keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if skmv == nil {
+		return kminvalues.NewKMinValues(s.KeySize(sgr.Key)), KeyNotFound
+	}
+	return skmv.Since(sgr.Since), nil
+}
+
+// Reads a sliding window set.  If the key doesn't exist a nil set is returned.
+func getSlidingKMinValues(database Backend, keyBytes []byte) (*kminvalues.SlidingKMinValues, error) {
+	data, err := database.Get(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return kminvalues.SlidingKMinValuesFromBytes(data)
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestSliding(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	key := "_GOTEST_SLIDING"
+	resultChan := make(chan Result)
+	testStore.Do(DeleteRequest{
+		Key:        SlidingPrefix + key,
+		ResultChan: resultChan,
+	})
+	<-resultChan
+
+	for i := 0; i < 100; i++ {
+		testStore.Do(SlidingAddRequest{
+			Key:        key,
+			Hash:       GetRandHash(),
+			Seen:       int64(1000 + i),
+			Size:       1000,
+			Window:     60,
+			ResultChan: resultChan,
+		})
+		result := <-resultChan
+		assert.Equal(t, result.Error, nil)
+	}
+
+	testStore.Do(SlidingGetRequest{
+		Key:        key,
+		Since:      1090,
+		ResultChan: resultChan,
+	})
+	result := <-resultChan
+	assert.Equal(t, result.Error, nil)
+	assert.Equal(t, result.Data.Cardinality(), 10.0)
+
+	testStore.Do(SlidingGetRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	})
+	result = <-resultChan
+	assert.Equal(t, result.Data.Cardinality(), 61.0)
+
+	testStore.Do(GetRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	})
+	result = <-resultChan
+	assert.Equal(t, result.Error, KeyNotFound)
+
+	testStore.Do(DeleteRequest{
+		Key:        SlidingPrefix + key,
+		ResultChan: resultChan,
+	})
+	<-resultChan
+	testStore.Do(SlidingGetRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	})
+	result = <-resultChan
+	assert.Equal(t, result.Error, KeyNotFound)
+}
@@ -0,0 +1,272 @@
+// Package store keeps KMin Value sets in a key-value Backend, such as LevelDB
+// or Bolt, and answers queries over them.  It is what the gocountme server
+// runs on and can be embedded in any other program:
+//
+//	s, err := store.Open("./db", store.DefaultOptions())
+//	defer s.Close()
+//	s.Add("users", "alice")
+//	estimate, err := s.Cardinality("users")
+//
+// Every request runs on one of the store's workers, so a Store can be used
+// from many goroutines at once.
+package store
+
+import (
+	"encoding/binary"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"github.com/reusee/mmh3"
+	"log"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// Name of the backend Open stores sets in: leveldb, bolt or memory.
+	// LevelDB is only available in binaries built with cgo.
+	Backend string
+	// Number of workers running requests against the database
+	Workers int
+	// Size of new KMin Value sets whose key doesn't match any of KeySizes
+	DefaultSize int
+	// Maps key prefixes to the size new sets under that prefix are created
+	// with, see LoadKeySizes
+	KeySizes map[string]int
+	// Maps key prefixes to how long keys under that prefix are kept, see
+	// LoadRetentionRules
+	RetentionRules map[string]time.Duration
+	// Number of sets to keep in the write-back cache, 0 disables the cache
+	CacheSize int
+	// How often dirty sets in the cache are written to the backend
+	CacheFlush time.Duration
+	// How often expired keys are deleted and old buckets are merged, 0
+	// disables compaction
+	CompactInterval time.Duration
+	// Merge minute buckets this old into hour buckets, 0 disables
+	RollupMinutes time.Duration
+	// Merge hour buckets this old into day buckets, 0 disables
+	RollupHours time.Duration
+	// Called with the stats of every compaction that runs on its own
+	OnCompact func(CompactStats)
+	// LRU cache size for LevelDB in bytes
+	LevelDBCache int
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Backend:         "leveldb",
+		Workers:         1,
+		DefaultSize:     1024,
+		CacheFlush:      10 * time.Second,
+		CompactInterval: 5 * time.Minute,
+		LevelDBCache:    1 << 16,
+	}
+}
+
+type Store struct {
+	db       Backend
+	options  Options
+	requests chan RequestCommand
+	workers  sync.WaitGroup
+	keyLocks [nKeyLocks]sync.Mutex
+
+	// Write-back cache of decoded sets shared by every worker.  When nil,
+	// sets are read from and written to the backend directly.
+	cache *SketchCache
+
+	// Stops the cache flusher and the compactor
+	stop       chan bool
+	background sync.WaitGroup
+
+	// Held while sending to requests so that Close never closes the channel
+	// under a request that is being sent
+	closeLock sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Opens the database at path with the backend named in the options, creating
+// it if it doesn't exist, and starts the store's workers.  Options left at
+// zero fall back to DefaultOptions, except for the cache and compaction which
+// stay disabled.
+func Open(path string, options Options) (*Store, error) {
+	if options.Backend == "" {
+		options.Backend = DefaultOptions().Backend
+	}
+	if options.LevelDBCache <= 0 {
+		options.LevelDBCache = DefaultOptions().LevelDBCache
+	}
+	backend, err := openBackend(options.Backend, path, options)
+	if err != nil {
+		return nil, err
+	}
+	return New(backend, options), nil
+}
+
+// Creates a store on top of an open backend, which the store closes when it
+// is closed
+func New(backend Backend, options Options) *Store {
+	defaults := DefaultOptions()
+	if options.Workers <= 0 {
+		options.Workers = defaults.Workers
+	}
+	if options.DefaultSize <= 0 {
+		options.DefaultSize = defaults.DefaultSize
+	}
+	if options.CacheFlush <= 0 {
+		options.CacheFlush = defaults.CacheFlush
+	}
+
+	s := &Store{
+		db:       backend,
+		options:  options,
+		requests: make(chan RequestCommand, options.Workers),
+		stop:     make(chan bool),
+	}
+	s.workers.Add(options.Workers)
+	for i := 0; i < options.Workers; i++ {
+		go s.worker()
+	}
+	if options.CacheSize > 0 {
+		s.cache = NewSketchCache(options.CacheSize)
+		s.every(options.CacheFlush, func(time.Time) {
+			if err := s.cache.Flush(s.db); err != nil {
+				log.Printf("Could not flush cache: %s", err)
+			}
+		})
+	}
+	if options.CompactInterval > 0 {
+		s.every(options.CompactInterval, func(now time.Time) {
+			stats, err := s.Compact(now)
+			if err != nil {
+				log.Printf("Could not compact: %s", err)
+			}
+			if options.OnCompact != nil {
+				options.OnCompact(stats)
+			}
+		})
+	}
+	return s
+}
+
+// Calls f every interval until the store is closed
+func (s *Store) every(interval time.Duration, f func(time.Time)) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				f(now)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *Store) worker() {
+	defer s.workers.Done()
+	for request := range s.requests {
+		kmv, err := request.Execute(s)
+		request.WriteResult(Result{
+			Data:  kmv,
+			Error: err,
+		})
+	}
+}
+
+// Waits for every request that was sent to finish, writes out the cache and
+// closes the backend.  The store can't be used once it is closed.  Closing it
+// again returns the error of the first Close.
+func (s *Store) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		s.background.Wait()
+		s.closeLock.Lock()
+		s.closed = true
+		s.closeLock.Unlock()
+		close(s.requests)
+		s.workers.Wait()
+
+		if s.cache != nil {
+			s.closeErr = s.cache.Flush(s.db)
+		}
+		if err := s.db.Close(); s.closeErr == nil {
+			s.closeErr = err
+		}
+	})
+	return s.closeErr
+}
+
+// Queues a request for the workers.  The result is sent on the request's
+// result channel, so unless the caller waits for it the channel needs room
+// for it.  Requests made once the store is closed get StoreClosed.
+func (s *Store) Do(request RequestCommand) {
+	s.closeLock.RLock()
+	defer s.closeLock.RUnlock()
+	if s.closed {
+		go request.WriteResult(Result{Error: StoreClosed})
+		return
+	}
+	s.requests <- request
+}
+
+func Hashify(orig []byte) uint64 {
+	h := mmh3.Hash128(orig)
+	return binary.LittleEndian.Uint64(h)
+}
+
+// Adds value to the set at key, creating the set if it doesn't exist yet
+func (s *Store) Add(key string, value string) error {
+	return s.AddHash(key, Hashify([]byte(value)))
+}
+
+func (s *Store) AddHash(key string, hash uint64) error {
+	resultChan := make(chan Result, 1)
+	s.Do(AddHashRequest{
+		Key:        key,
+		Hash:       hash,
+		ResultChan: resultChan,
+	})
+	return (<-resultChan).Error
+}
+
+// Returns the set stored at key or KeyNotFound if there is none
+func (s *Store) Get(key string) (kminvalues.Sketch, error) {
+	resultChan := make(chan Result, 1)
+	s.Do(GetRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	})
+	result := <-resultChan
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Data, nil
+}
+
+func (s *Store) Delete(key string) error {
+	resultChan := make(chan Result, 1)
+	s.Do(DeleteRequest{
+		Key:        key,
+		ResultChan: resultChan,
+	})
+	return (<-resultChan).Error
+}
+
+// Estimates the number of items in the set at key
+func (s *Store) Cardinality(key string) (*Estimate, error) {
+	sketch, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return SketchEstimate(sketch, DefaultConfidence), nil
+}
+
+// Returns the size new KMin Value sets at key are created with
+func (s *Store) KeySize(key string) int {
+	return keySize(s.options.KeySizes, s.options.DefaultSize, key)
+}
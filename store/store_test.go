@@ -0,0 +1,41 @@
+package store
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	key := "_GOTEST_STORE"
+	testStore.Delete(key)
+	defer testStore.Delete(key)
+
+	_, err := testStore.Get(key)
+	assert.Equal(t, err, KeyNotFound)
+
+	for _, value := range []string{"a", "b", "b", "c"} {
+		assert.Equal(t, testStore.Add(key, value), nil)
+	}
+	estimate, err := testStore.Cardinality(key)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *estimate, Estimate{3, 3, 3, DefaultConfidence, true})
+
+	sketch, err := testStore.Get(key)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sketch.MaxSize(), 1024)
+
+	result, err := testStore.Query([]byte(`{"method": "cardinality", "keys": ["_GOTEST_STORE"]}`))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Num, 3.0)
+}
+
+func TestStoreClosed(t *testing.T) {
+	s := New(NewMemoryBackend(), Options{})
+	assert.Equal(t, s.Add("_GOTEST_CLOSED", "a"), nil)
+	assert.Equal(t, s.Close(), nil)
+	assert.Equal(t, s.Add("_GOTEST_CLOSED", "b"), StoreClosed)
+	assert.Equal(t, s.Close(), nil)
+}
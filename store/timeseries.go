@@ -0,0 +1,155 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"strconv"
+	"strings"
+)
+
+var (
+	InvalidBucket  = errors.New("Bucket must be one of minute, hour or day")
+	TooManyBuckets = errors.New("Time range covers too many buckets")
+	InvalidRange   = errors.New("Time range must have from <= to")
+)
+
+// Most buckets a single time range query will union together
+const maxRangeBuckets = 10000
+
+// Every time bucket key is also listed under this prefix so that compaction
+// can find them without going through every key
+const bucketIndexPrefix = internalPrefix + "bucket:"
+
+// Length in seconds of each bucket size a time series can be stored with
+var BucketSizes = map[string]int64{
+	"minute": 60,
+	"hour":   60 * 60,
+	"day":    24 * 60 * 60,
+}
+
+// The bucket size compaction merges each bucket size into
+var bucketRollups = map[string]string{
+	"minute": "hour",
+	"hour":   "day",
+}
+
+// Returns the key that the bucket holding the unix timestamp ts is stored
+// under.  Bucket keys look like `key@hour:001414368000` and sort by time.
+func BucketKey(key string, bucket string, ts int64) (string, error) {
+	size, ok := BucketSizes[bucket]
+	if !ok {
+		return "", InvalidBucket
+	}
+	start := ts - ts%size
+	return fmt.Sprintf("%s@%s:%012d", key, bucket, start), nil
+}
+
+// Splits a bucket key back up into the key, bucket and start time it was
+// created from
+func parseBucketKey(bkey string) (string, string, int64, bool) {
+	at := strings.LastIndex(bkey, "@")
+	if at < 0 {
+		return "", "", 0, false
+	}
+	parts := strings.SplitN(bkey[at+1:], ":", 2)
+	if len(parts) != 2 || len(parts[1]) != 12 {
+		return "", "", 0, false
+	}
+	if _, ok := BucketSizes[parts[0]]; !ok {
+		return "", "", 0, false
+	}
+	start, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", "", 0, false
+	}
+	return bkey[:at], parts[0], start, true
+}
+
+// Returns the keys of every bucket that overlaps the time range [from, to]
+func BucketKeys(key string, bucket string, from, to int64) ([]string, error) {
+	size, ok := BucketSizes[bucket]
+	if !ok {
+		return nil, InvalidBucket
+	}
+	if from > to {
+		return nil, InvalidRange
+	}
+	start := from - from%size
+	if (to-start)/size >= maxRangeBuckets {
+		return nil, TooManyBuckets
+	}
+
+	keys := make([]string, 0, (to-start)/size+1)
+	for ts := start; ts <= to; ts += size {
+		key, _ := BucketKey(key, bucket, ts)
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func bucketIndexKey(key string) []byte {
+	return []byte(bucketIndexPrefix + key)
+}
+
+// Adds key to the bucket index if it is a time bucket key and returns whether
+// it was
+func indexBucket(wb *Batch, key string) bool {
+	if _, _, _, ok := parseBucketKey(key); !ok {
+		return false
+	}
+	wb.Put(bucketIndexKey(key), []byte{1})
+	return true
+}
+
+// Lists every time bucket key in the bucket index
+func (s *Store) indexedBuckets() ([]string, error) {
+	keys := make([]string, 0)
+	err := s.db.Iterate([]byte(bucketIndexPrefix), func(keyBytes, value []byte) bool {
+		key := string(keyBytes)
+		if !strings.HasPrefix(key, bucketIndexPrefix) {
+			return false
+		}
+		keys = append(keys, key[len(bucketIndexPrefix):])
+		return true
+	})
+	return keys, err
+}
+
+// Returns the union of every bucket of key that overlaps the time range
+// [from, to].  Buckets that compaction merged into coarser ones are covered by
+// the coarser bucket instead, which can count items from just outside of the
+// range.
+func (s *Store) TimeRange(key string, bucket string, from, to int64) (kminvalues.Sketch, error) {
+	keys, err := BucketKeys(key, bucket, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	sketches := make([]kminvalues.Sketch, 0, len(keys))
+	for len(keys) > 0 {
+		data, found, err := s.getSets(keys)
+		if err != nil {
+			return nil, err
+		}
+		coarser, ok := bucketRollups[bucket]
+		missing := make([]string, 0)
+		for i, bkey := range keys {
+			if found[i] {
+				sketches = append(sketches, data[i])
+			} else if ok {
+				_, _, start, _ := parseBucketKey(bkey)
+				ckey, _ := BucketKey(key, coarser, start)
+				if len(missing) == 0 || missing[len(missing)-1] != ckey {
+					missing = append(missing, ckey)
+				}
+			}
+		}
+		keys, bucket = missing, coarser
+	}
+
+	if len(sketches) == 0 {
+		return kminvalues.NewKMinValues(s.KeySize(key)), nil
+	}
+	return kminvalues.UnionSketches(sketches...)
+}
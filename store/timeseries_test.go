@@ -0,0 +1,57 @@
+package store
+
+import (
+	"github.com/bmizerany/assert"
+	"testing"
+)
+
+func TestBucketKeys(t *testing.T) {
+	key, err := BucketKey("pageviews", "hour", 3600*5+17)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, key, "pageviews@hour:000000018000")
+
+	_, err = BucketKey("pageviews", "week", 0)
+	assert.Equal(t, err, InvalidBucket)
+
+	keys, err := BucketKeys("pageviews", "day", 86400+10, 3*86400)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, keys, []string{
+		"pageviews@day:000000086400",
+		"pageviews@day:000000172800",
+		"pageviews@day:000000259200",
+	})
+
+	_, err = BucketKeys("pageviews", "minute", 0, 60*maxRangeBuckets)
+	assert.Equal(t, err, TooManyBuckets)
+	_, err = BucketKeys("pageviews", "minute", 60, 0)
+	assert.Equal(t, err, InvalidRange)
+}
+
+func TestTimeRange(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	key := "_GOTEST_TIMERANGE"
+	buckets := map[string]int64{"minute": 0, "hour": 60, "day": 86400}
+	for bucket, ts := range buckets {
+		bkey, _ := BucketKey(key, bucket, ts)
+		assert.Equal(t, testStore.AddHash(bkey, uint64(ts+1)), nil)
+		defer testStore.Delete(bkey)
+	}
+
+	// the second minute only exists as part of the hour bucket
+	union, err := testStore.TimeRange(key, "minute", 0, 119)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, union.Cardinality(), 2.0)
+
+	union, err = testStore.TimeRange(key, "minute", 86400, 86460)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, union.Cardinality(), 1.0)
+
+	union, err = testStore.TimeRange(key, "hour", 2*86400, 2*86400+3600)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, union.Cardinality(), 0.0)
+
+	_, err = testStore.TimeRange(key, "week", 0, 60)
+	assert.Equal(t, err, InvalidBucket)
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"errors"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"math"
+)
+
+var (
+	WeightedSizeTooSmall = errors.New("Weighted sets need a size of at least 3")
+	InvalidWeight        = errors.New("Weights must be finite and positive")
+)
+
+// Smallest size a weighted set can be created with.  The error of the sum of
+// a full set any smaller than this can't be bounded.
+const minWeightedSize = 3
+
+// Weighted sets are stored under this prefix followed by their key since
+// they can't be used wherever a regular set can
+const WeightedPrefix = internalPrefix + "weighted:"
+
+type WeightedAddRequest struct {
+	Key        string
+	Hash       uint64
+	Value      float64
+	Size       int
+	Merge      kminvalues.Merge
+	ResultChan chan Result
+}
+
+type WeightedResult struct {
+	Key   string
+	Data  *kminvalues.WeightedKMinValues
+	Error error
+}
+
+// Fetches the weighted set stored at Key.  This is a pointer type since
+// Execute keeps the set around for WriteResult.
+type WeightedGetRequest struct {
+	Key        string
+	ResultChan chan WeightedResult
+	result     WeightedResult
+}
+
+func (war WeightedAddRequest) WriteResult(result Result) {
+	result.Key = war.Key
+	war.ResultChan <- result
+}
+func (wgr *WeightedGetRequest) WriteResult(result Result) {
+	wgr.result.Key = wgr.Key
+	wgr.result.Error = result.Error
+	wgr.ResultChan <- wgr.result
+}
+
+// Weights have to be finite and positive for the sum and mean estimates to
+// make sense
+func ValidWeight(weight float64) bool {
+	return weight > 0 && !math.IsInf(weight, 1)
+}
+
+func (war WeightedAddRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if war.Key == "" {
+		return nil, NoKeySpecified
+	}
+	if !ValidWeight(war.Value) {
+		return nil, InvalidWeight
+	}
+	keyBytes := []byte(WeightedPrefix + war.Key)
+	defer s.lockKeys(string(keyBytes))()
+
+	wkmv, err := getWeightedKMinValues(s.db, keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if wkmv == nil {
+		size := war.Size
+		if size <= 0 {
+			size = s.KeySize(war.Key)
+		}
+		if size < minWeightedSize {
+			return nil, WeightedSizeTooSmall
+		}
+		wkmv = kminvalues.NewWeightedKMinValues(size, war.Merge)
+	}
+	if !wkmv.AddHash(war.Hash, war.Value) {
+		return nil, nil
+	}
+
+	err = s.db.Put(keyBytes, wkmv.Bytes())
+	return nil, err
+}
+
+func (wgr *WeightedGetRequest) Execute(s *Store) (kminvalues.Sketch, error) {
+	if wgr.Key == "" {
+		return nil, NoKeySpecified
+	}
+	keyBytes := []byte(WeightedPrefix + wgr.Key)
+	defer s.lockKeys(string(keyBytes))()
+
+	wkmv, err := getWeightedKMinValues(s.db, keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if wkmv == nil {
+		wgr.result.Data = kminvalues.NewWeightedKMinValues(s.KeySize(wgr.Key), kminvalues.MergeSum)
+		return nil, KeyNotFound
+	}
+	wgr.result.Data = wkmv
+	return nil, nil
+}
+
+// Reads a weighted set.  If the key doesn't exist a nil set is returned.
+func getWeightedKMinValues(database Backend, keyBytes []byte) (*kminvalues.WeightedKMinValues, error) {
+	data, err := database.Get(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return kminvalues.WeightedKMinValuesFromBytes(data)
+}
+
+// Fetches the weighted set for every key in the same order as the keys.  Keys
+// that don't exist give empty sets.
+func (s *Store) GetWeightedSets(keys []string) ([]*kminvalues.WeightedKMinValues, error) {
+	resultChan := make(chan WeightedResult)
+	defer close(resultChan)
+
+	data := make([]*kminvalues.WeightedKMinValues, len(keys))
+	for i, key := range keys {
+		s.Do(&WeightedGetRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		})
+		result := <-resultChan
+		if result.Error != nil && result.Error != KeyNotFound {
+			return nil, result.Error
+		}
+		data[i] = result.Data
+	}
+	return data, nil
+}
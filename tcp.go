@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"github.com/mynameisfiber/gocountme/store"
+	"io"
+	"log"
+	"math"
+	"net"
+)
+
+// The binary protocol served by --tcp.  Every request and response is a frame
+// made of a big-endian uint32 giving the length of the rest of the frame
+// followed by that many bytes.
+//
+// Requests start with an opcode followed by the key as a big-endian uint16
+// length and the key's bytes:
+//
+//	ADD      key, uint32 k, value (the rest of the frame)
+//	ADDHASH  key, uint32 k, uint64 hash
+//	GET      key
+//	CARD     key
+//	DELETE   key
+//
+// A k of 0 takes the size of new sets from the key size config.  Responses
+// start with a status byte.  Errors are followed by the error message, GET is
+// followed by the stored set and CARD by its cardinality as a big-endian
+// float64.  Requests can be pipelined and responses are written in the order
+// the requests were read.  With more than one worker pipelined requests may
+// be run in a different order, the same as concurrent HTTP requests.
+const (
+	tcpOpAdd byte = iota + 1
+	tcpOpAddHash
+	tcpOpGet
+	tcpOpCard
+	tcpOpDelete
+)
+
+const (
+	tcpStatusOK byte = iota
+	tcpStatusError
+)
+
+// Largest frame that is accepted.  Connections sending anything larger are
+// closed since the rest of the stream can't be trusted.
+const maxFrameSize = 1 << 20
+
+// Number of requests a single connection can have in flight before we stop
+// reading from it
+const maxPipelined = 1024
+
+var (
+	InvalidFrame       = errors.New("Malformed request frame")
+	FrameTooLarge      = errors.New("Request frame is too large")
+	UnknownOpcode      = errors.New("Unknown opcode")
+	ServerShuttingDown = errors.New("Server is shutting down")
+)
+
+// A request that was read off of a connection.  Requests that couldn't be
+// sent to the workers only have err set.
+type tcpPending struct {
+	op         byte
+	resultChan chan store.Result
+	err        error
+}
+
+// Accepts connections on listener until it is closed
+func ServeTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go serveTCPConn(conn)
+	}
+}
+
+func serveTCPConn(conn net.Conn) {
+	defer conn.Close()
+
+	pending := make(chan tcpPending, maxPipelined)
+	done := make(chan bool)
+	go func() {
+		writeTCPResponses(conn, pending)
+		close(done)
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		frame, err := readFrame(reader)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			pending <- tcpPending{err: err}
+			break
+		}
+		pending <- dispatchTCP(frame)
+	}
+	close(pending)
+	<-done
+}
+
+func readFrame(reader io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return nil, FrameTooLarge
+	}
+	frame := make([]byte, size)
+	_, err := io.ReadFull(reader, frame)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return frame, err
+}
+
+func writeFrame(writer io.Writer, status byte, body []byte) error {
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header, uint32(len(body)+1))
+	header[4] = status
+	if _, err := writer.Write(header); err != nil {
+		return err
+	}
+	_, err := writer.Write(body)
+	return err
+}
+
+// Splits a uint16 length prefixed string off of the front of body
+func readFrameString(body []byte) (string, []byte, bool) {
+	if len(body) < 2 {
+		return "", nil, false
+	}
+	size := int(binary.BigEndian.Uint16(body))
+	if len(body) < 2+size {
+		return "", nil, false
+	}
+	return string(body[2 : 2+size]), body[2+size:], true
+}
+
+// Turns a frame into a request and sends it to the workers without waiting
+// for the result
+func dispatchTCP(frame []byte) tcpPending {
+	if len(frame) == 0 {
+		return tcpPending{err: InvalidFrame}
+	}
+	op := frame[0]
+	key, body, ok := readFrameString(frame[1:])
+	if !ok {
+		return tcpPending{op: op, err: InvalidFrame}
+	}
+
+	// buffered so that workers never wait on the connection's writer
+	resultChan := make(chan store.Result, 1)
+	var request store.RequestCommand
+	switch op {
+	case tcpOpAdd, tcpOpAddHash:
+		if len(body) < 4 {
+			return tcpPending{op: op, err: InvalidFrame}
+		}
+		size := int(binary.BigEndian.Uint32(body))
+		body = body[4:]
+
+		var hash uint64
+		if op == tcpOpAdd {
+			if len(body) == 0 {
+				return tcpPending{op: op, err: InvalidFrame}
+			}
+			hash = store.Hashify(body)
+		} else {
+			if len(body) != 8 {
+				return tcpPending{op: op, err: InvalidFrame}
+			}
+			hash = binary.BigEndian.Uint64(body)
+		}
+		request = store.AddHashRequest{
+			Key:        key,
+			Hash:       hash,
+			Size:       size,
+			ResultChan: resultChan,
+		}
+	case tcpOpGet, tcpOpCard:
+		if len(body) != 0 {
+			return tcpPending{op: op, err: InvalidFrame}
+		}
+		request = store.GetRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		}
+	case tcpOpDelete:
+		if len(body) != 0 {
+			return tcpPending{op: op, err: InvalidFrame}
+		}
+		request = store.DeleteRequest{
+			Key:        key,
+			ResultChan: resultChan,
+		}
+	default:
+		return tcpPending{op: op, err: UnknownOpcode}
+	}
+
+	// registered like the requests shutdownGate lets through so that nothing
+	// gets sent to the workers once requests have been drained
+	if !beginRequest() {
+		return tcpPending{op: op, err: ServerShuttingDown}
+	}
+	defer endRequest()
+	sketchStore.Do(request)
+	return tcpPending{op: op, resultChan: resultChan}
+}
+
+// Writes the response to every pending request in order.  Responses are
+// buffered and only flushed once there are no more requests waiting so that
+// pipelined requests get written out together.
+func writeTCPResponses(conn net.Conn, pending chan tcpPending) {
+	writer := bufio.NewWriter(conn)
+	var err error
+	for request := range pending {
+		status, body := tcpStatusError, []byte(nil)
+		if request.err != nil {
+			body = []byte(request.err.Error())
+		} else {
+			status, body = tcpResponse(request.op, <-request.resultChan)
+		}
+
+		// keep draining pending after an error so the reader never blocks
+		if err != nil {
+			continue
+		}
+		err = writeFrame(writer, status, body)
+		if err == nil && len(pending) == 0 {
+			err = writer.Flush()
+		}
+		if err != nil {
+			log.Printf("Could not write TCP response: %s", err)
+			conn.Close()
+		}
+	}
+}
+
+func tcpResponse(op byte, result store.Result) (byte, []byte) {
+	if result.Error != nil {
+		return tcpStatusError, []byte(result.Error.Error())
+	}
+	switch op {
+	case tcpOpGet:
+		return tcpStatusOK, result.Data.Bytes()
+	case tcpOpCard:
+		body := make([]byte, 8)
+		binary.BigEndian.PutUint64(body, math.Float64bits(result.Data.Cardinality()))
+		return tcpStatusOK, body
+	}
+	return tcpStatusOK, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"github.com/mynameisfiber/gocountme/store"
+	"math"
+	"net"
+	"testing"
+)
+
+func tcpFrame(op byte, key string, body ...[]byte) []byte {
+	frame := []byte{op, 0, 0}
+	binary.BigEndian.PutUint16(frame[1:], uint16(len(key)))
+	frame = append(frame, key...)
+	for _, b := range body {
+		frame = append(frame, b...)
+	}
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len(frame)))
+	return append(header, frame...)
+}
+
+func uint32Bytes(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return b
+}
+
+func uint64Bytes(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b
+}
+
+func TestTCPPipelining(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, err, nil)
+	defer listener.Close()
+	go ServeTCP(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	assert.Equal(t, err, nil)
+	defer conn.Close()
+
+	key := "_GOTEST_TCP"
+	var requests bytes.Buffer
+	requests.Write(tcpFrame(tcpOpDelete, key))
+	requests.Write(tcpFrame(tcpOpAdd, key, uint32Bytes(10), []byte("value1")))
+	requests.Write(tcpFrame(tcpOpAdd, key, uint32Bytes(10), []byte("value2")))
+	requests.Write(tcpFrame(tcpOpAddHash, key, uint32Bytes(10), uint64Bytes(store.Hashify([]byte("value1")))))
+	requests.Write(tcpFrame(tcpOpCard, key))
+	requests.Write(tcpFrame(tcpOpGet, key))
+	requests.Write(tcpFrame(42, key))
+	requests.Write(tcpFrame(tcpOpAddHash, key, uint32Bytes(10)))
+	requests.Write(tcpFrame(tcpOpDelete, key))
+	requests.Write(tcpFrame(tcpOpCard, key))
+	_, err = conn.Write(requests.Bytes())
+	assert.Equal(t, err, nil)
+
+	reader := bufio.NewReader(conn)
+	readResponse := func() (byte, []byte) {
+		frame, err := readFrame(reader)
+		assert.Equal(t, err, nil)
+		return frame[0], frame[1:]
+	}
+
+	for i := 0; i < 4; i++ {
+		status, body := readResponse()
+		assert.Equal(t, status, tcpStatusOK)
+		assert.Equal(t, len(body), 0)
+	}
+
+	status, body := readResponse()
+	assert.Equal(t, status, tcpStatusOK)
+	assert.Equal(t, math.Float64frombits(binary.BigEndian.Uint64(body)), 2.0)
+
+	status, body = readResponse()
+	assert.Equal(t, status, tcpStatusOK)
+	sketch, err := kminvalues.SketchFromBytes(body)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sketch.Cardinality(), 2.0)
+	assert.Equal(t, sketch.MaxSize(), 10)
+
+	status, body = readResponse()
+	assert.Equal(t, status, tcpStatusError)
+	assert.Equal(t, string(body), UnknownOpcode.Error())
+
+	status, body = readResponse()
+	assert.Equal(t, status, tcpStatusError)
+	assert.Equal(t, string(body), InvalidFrame.Error())
+
+	status, _ = readResponse()
+	assert.Equal(t, status, tcpStatusOK)
+
+	status, body = readResponse()
+	assert.Equal(t, status, tcpStatusError)
+	assert.Equal(t, string(body), store.KeyNotFound.Error())
+}
+
+func TestTCPFrameTooLarge(t *testing.T) {
+	server, client := net.Pipe()
+	go serveTCPConn(server)
+	defer client.Close()
+
+	go client.Write(uint32Bytes(maxFrameSize + 1))
+	frame, err := readFrame(client)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, frame[0], tcpStatusError)
+	assert.Equal(t, string(frame[1:]), FrameTooLarge.Error())
+}
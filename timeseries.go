@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/mynameisfiber/gocountme/store"
+	"net/url"
+	"strconv"
+)
+
+// Reads the `bucket` parameter, falling back to --bucket
+func bucketParam(reqParams url.Values) (string, bool) {
+	bucket := reqParams.Get("bucket")
+	if bucket == "" {
+		bucket = *defaultBucket
+	}
+	_, ok := store.BucketSizes[bucket]
+	return bucket, ok
+}
+
+// Reads a unix timestamp parameter
+func timeParam(reqParams url.Values, name string) (int64, bool) {
+	ts, err := strconv.ParseInt(reqParams.Get(name), 10, 64)
+	if err != nil || ts < 0 {
+		return 0, false
+	}
+	return ts, true
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bmizerany/assert"
+	"github.com/mynameisfiber/gocountme/store"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTimeRangeCardinality(t *testing.T) {
+	SetupDB()
+	defer CloseDB()
+
+	key := "_GOTEST_TIMESERIES"
+	resultChan := make(chan store.Result)
+	for hour := int64(0); hour < 3; hour++ {
+		bucket, _ := store.BucketKey(key, "hour", hour*3600)
+		defer func() {
+			sketchStore.Do(store.DeleteRequest{
+				Key:        bucket,
+				ResultChan: resultChan,
+			})
+			<-resultChan
+		}()
+	}
+
+	// Every hour sees the values 0-9 and one value of its own
+	for hour := 0; hour < 3; hour++ {
+		for i := 0; i < 10; i++ {
+			uri := fmt.Sprintf("/add?key=%s&value=%d&ts=%d", key, i, hour*3600+i)
+			AddHandler(httptest.NewRecorder(), httptest.NewRequest("GET", uri, nil))
+		}
+		uri := fmt.Sprintf("/add?key=%s&value=hour%d&ts=%d", key, hour, hour*3600)
+		AddHandler(httptest.NewRecorder(), httptest.NewRequest("GET", uri, nil))
+	}
+
+	for to, expected := range map[int]float64{0: 11, 3599: 11, 3600: 12, 3 * 3600: 13} {
+		uri := fmt.Sprintf("/cardinality?key=%s&from=0&to=%d&bucket=hour", key, to)
+		w := httptest.NewRecorder()
+		CardinalityHandler(w, httptest.NewRequest("GET", uri, nil))
+		assert.Equal(t, w.Code, 200)
+
+		var response struct {
+			Data store.Estimate `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, response.Data, store.Estimate{Estimate: expected, Lower: expected, Upper: expected, Confidence: store.DefaultConfidence, Exact: true})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"github.com/mynameisfiber/gocountme/kminvalues"
+	"github.com/mynameisfiber/gocountme/store"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func AddWeightedHandler(w http.ResponseWriter, r *http.Request) {
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	key := reqParams.Get("key")
+	if key == "" {
+		HttpError(w, 500, "MISSING_ARG_KEY")
+		return
+	}
+
+	value := reqParams.Get("value")
+	if value == "" {
+		HttpError(w, 500, "MISSING_ARG_VALUE")
+		return
+	}
+
+	weightRaw := reqParams.Get("weight")
+	if weightRaw == "" {
+		HttpError(w, 500, "MISSING_ARG_WEIGHT")
+		return
+	}
+	weight, err := strconv.ParseFloat(weightRaw, 64)
+	if err != nil {
+		HttpError(w, 500, "INVALID_ARG_WEIGHT")
+		return
+	}
+	if !store.ValidWeight(weight) {
+		HttpError(w, 400, "INVALID_ARG_WEIGHT")
+		return
+	}
+
+	size, ok := sizeParam(reqParams)
+	if !ok {
+		HttpError(w, 500, "INVALID_ARG_K")
+		return
+	}
+
+	merge := kminvalues.MergeSum
+	if reqParams.Get("merge") != "" {
+		merge, err = kminvalues.ParseMerge(reqParams.Get("merge"))
+		if err != nil {
+			HttpError(w, 500, "INVALID_ARG_MERGE")
+			return
+		}
+	}
+
+	resultChan := make(chan store.Result)
+	weightedAddRequest := store.WeightedAddRequest{
+		Key:        key,
+		Hash:       store.Hashify([]byte(value)),
+		Value:      weight,
+		Size:       size,
+		Merge:      merge,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(weightedAddRequest)
+	result := <-resultChan
+	close(resultChan)
+	if result.Error == nil {
+		HttpResponse(w, 200, "OK")
+	} else {
+		HttpResponse(w, 500, result.Error.Error())
+	}
+}
+
+func DeleteWeightedHandler(w http.ResponseWriter, r *http.Request) {
+	reqParams, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		HttpError(w, 500, "INVALID_URI")
+		return
+	}
+
+	key := reqParams.Get("key")
+	if key == "" {
+		HttpError(w, 500, "MISSING_ARG_KEY")
+		return
+	}
+
+	resultChan := make(chan store.Result)
+	deleteRequest := store.DeleteRequest{
+		Key:        store.WeightedPrefix + key,
+		ResultChan: resultChan,
+	}
+	sketchStore.Do(deleteRequest)
+	result := <-resultChan
+	close(resultChan)
+	if result.Error == nil {
+		HttpResponse(w, 200, "OK")
+	} else {
+		HttpResponse(w, 500, result.Error.Error())
+	}
+}